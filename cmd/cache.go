@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/pol-cova/GoGinit/config"
+	"github.com/pol-cova/GoGinit/runner"
+	"github.com/pol-cova/GoGinit/scaffold"
+	"github.com/spf13/cobra"
+)
+
+var cacheFlags struct {
+	proxyDir   string
+	frameworks []string
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the module cache used by offline scaffolding",
+}
+
+var cacheWarmCmd = &cobra.Command{
+	Use:   "warm",
+	Short: "Download the dependencies of every framework for offline use",
+	Long: `Download the modules needed to scaffold every registered framework, with
+the database setup, so that 'goginit init --offline' works without network
+access. With --proxy-dir the modules are written to a directory usable as a
+file-based GOPROXY instead of the local module cache.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names := cacheFlags.frameworks
+		if len(names) == 0 {
+			names = config.FrameworkNames()
+		}
+		for _, name := range names {
+			if _, err := config.GetFrameworkConfig(name); err != nil {
+				return err
+			}
+		}
+
+		if cacheFlags.proxyDir == "" {
+			return warmCache(cmd.Context(), newRunner(), names)
+		}
+		return warmProxyDir(cmd.Context(), newRunner(), names, cacheFlags.proxyDir)
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheWarmCmd)
+
+	cacheWarmCmd.Flags().StringVar(&cacheFlags.proxyDir, "proxy-dir", "", "Write the modules to a file-based GOPROXY directory instead of the module cache")
+	cacheWarmCmd.Flags().StringSliceVarP(&cacheFlags.frameworks, "framework", "f", nil, "Framework to warm the cache for, can be repeated (default: all)")
+}
+
+// warmCache scaffolds a throwaway project for every framework, which
+// downloads its modules into the module cache
+func warmCache(ctx context.Context, r runner.Runner, frameworks []string) error {
+	tmp, err := os.MkdirTemp("", "goginit-warm-*")
+	if err != nil {
+		return fmt.Errorf("error creating temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	for _, name := range frameworks {
+		spec := config.ProjectSpec{
+			Name:      "warm" + name,
+			Module:    "goginit.local/warm" + name,
+			Framework: name,
+			Database:  config.DatabaseSQLite,
+		}
+		fmt.Printf("Warming the module cache for %s\n", name)
+
+		result, err := scaffold.Generate(ctx, scaffold.Options{
+			Spec:       spec,
+			Dir:        tmp,
+			Runner:     r,
+			SkipVerify: true,
+			Output:     os.Stdout,
+		})
+		if err != nil {
+			return fmt.Errorf("error warming the cache for %s: %w", name, err)
+		}
+		root := result.Dir
+
+		// Also fetch the test dependencies of everything the project imports
+		if result, err := r.Run(ctx, runner.Go(root, "mod", "tidy")); err != nil {
+			return fmt.Errorf("error warming the cache for %s: %v, Output: %s", name, err, result.Output)
+		}
+	}
+
+	fmt.Println("Module cache warmed, goginit init --offline is ready to use")
+	return nil
+}
+
+// warmProxyDir warms a temporary module cache and copies its download cache,
+// which has the layout of a GOPROXY, into proxyDir
+func warmProxyDir(ctx context.Context, r runner.Runner, frameworks []string, proxyDir string) error {
+	modCache, err := os.MkdirTemp("", "goginit-modcache-*")
+	if err != nil {
+		return fmt.Errorf("error creating temporary directory: %w", err)
+	}
+	r = runner.WithEnv(r, "GOMODCACHE="+modCache)
+	defer func() {
+		// The module cache is read-only, go clean knows how to remove it
+		_, _ = r.Run(context.Background(), runner.Go("", "clean", "-modcache"))
+		os.RemoveAll(modCache)
+	}()
+
+	if err := warmCache(ctx, r, frameworks); err != nil {
+		return err
+	}
+
+	download := filepath.Join(modCache, "cache", "download")
+	err = filepath.WalkDir(download, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(download, path)
+		if err != nil {
+			return err
+		}
+		// Lock files and partial downloads are not part of the proxy layout
+		if filepath.Ext(path) == ".lock" || filepath.Ext(path) == ".partial" {
+			return nil
+		}
+
+		target := filepath.Join(proxyDir, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0644)
+	})
+	if err != nil {
+		return fmt.Errorf("error writing proxy directory: %w", err)
+	}
+
+	fmt.Printf("Proxy directory written to %s, use it with goginit init --proxy-dir %s\n", proxyDir, proxyDir)
+	return nil
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pol-cova/GoGinit/scaffold"
+)
+
+// treeNode is a directory or file in the dry-run tree
+type treeNode struct {
+	children map[string]*treeNode
+	size     int
+	isFile   bool
+}
+
+// printDryRun prints the files scaffold.Generate would write for opts and
+// the commands it would run, without touching the disk
+func printDryRun(ctx context.Context, w io.Writer, opts scaffold.Options, showContents bool) error {
+	plan, err := scaffold.PlanProject(ctx, opts)
+	if err != nil {
+		return err
+	}
+	files := plan.Files
+
+	fmt.Fprintln(w, "Dry run, nothing will be written to disk.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Files:")
+	printTree(w, plan.Dir, plan.Dirs, files)
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Commands (run in %s, they also create go.mod and go.sum):\n", plan.Dir)
+	for _, command := range plan.Commands {
+		fmt.Fprintf(w, "  %s\n", command)
+	}
+
+	if showContents {
+		paths := make([]string, 0, len(files))
+		for path := range files {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			fmt.Fprintf(w, "\n==> %s <==\n", filepath.ToSlash(filepath.Join(plan.Dir, path)))
+			content := files[path]
+			if content != "" && !strings.HasSuffix(content, "\n") {
+				content += "\n"
+			}
+			fmt.Fprint(w, content)
+		}
+	}
+	return nil
+}
+
+// printTree prints dirs and files, given relative to root, as a tree with
+// the size of every file
+func printTree(w io.Writer, root string, dirs []string, files map[string]string) {
+	top := &treeNode{children: map[string]*treeNode{}}
+	insert := func(path string) *treeNode {
+		node := top
+		for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+			child, ok := node.children[part]
+			if !ok {
+				child = &treeNode{children: map[string]*treeNode{}}
+				node.children[part] = child
+			}
+			node = child
+		}
+		return node
+	}
+
+	for _, dir := range dirs {
+		insert(dir)
+	}
+	for path, content := range files {
+		node := insert(path)
+		node.isFile = true
+		node.size = len(content)
+	}
+
+	fmt.Fprintf(w, "%s/\n", root)
+	printTreeNode(w, top, "")
+}
+
+// printTreeNode prints the children of node, directories first
+func printTreeNode(w io.Writer, node *treeNode, prefix string) {
+	names := make([]string, 0, len(node.children))
+	for name := range node.children {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := node.children[names[i]], node.children[names[j]]
+		if a.isFile != b.isFile {
+			return !a.isFile
+		}
+		return names[i] < names[j]
+	})
+
+	for i, name := range names {
+		child := node.children[name]
+		branch, indent := "├── ", "│   "
+		if i == len(names)-1 {
+			branch, indent = "└── ", "    "
+		}
+
+		if child.isFile {
+			fmt.Fprintf(w, "%s%s%s (%d B)\n", prefix, branch, name, child.size)
+			continue
+		}
+		fmt.Fprintf(w, "%s%s%s/\n", prefix, branch, name)
+		printTreeNode(w, child, prefix+indent)
+	}
+}
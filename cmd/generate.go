@@ -0,0 +1,380 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pol-cova/GoGinit/config"
+	"github.com/pol-cova/GoGinit/internal/routing"
+	"github.com/pol-cova/GoGinit/scaffold"
+	"github.com/spf13/cobra"
+)
+
+// generateFlags holds the values shared by the generate subcommands
+var generateFlags struct {
+	dir       string
+	framework string
+	force     bool
+}
+
+// handlerFlags holds the values passed to generate handler
+var handlerFlags struct {
+	method string
+	path   string
+}
+
+// routeFlags holds the values passed to generate route
+var routeFlags struct {
+	method     string
+	file       string
+	importPath string
+}
+
+// middlewareFlags holds the values passed to generate middleware
+var middlewareFlags struct {
+	custom string
+}
+
+// modelFlags holds the values passed to generate model
+var modelFlags struct {
+	primaryKey string
+	timestamps bool
+}
+
+var generateCmd = &cobra.Command{
+	Use:     "generate",
+	Aliases: []string{"g"},
+	Short:   "Generate code in an existing project",
+	Long: `Generate code in an existing project, written for the framework the
+project is built on. The framework is detected from the framework packages
+imported by the module, use --framework when it cannot be.`,
+}
+
+var generateHandlerCmd = &cobra.Command{
+	Use:   "handler <Name>",
+	Short: "Generate an HTTP handler and its test",
+	Long: `Generate an HTTP handler in internal/handlers, with the signature of the
+project's framework, and a test requesting it through the framework's router.
+
+The name is turned into an exported Go identifier and a snake_case file name.
+Path parameters are written :name or {name} whatever the framework, and are
+read from the request in the generated handler.`,
+	Example: `  goginit generate handler GetUser --method GET --path /users/:id
+  goginit g handler create_order --method POST --path /orders`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := openProject(cmd.Context(), generateFlags.dir, generateFlags.framework)
+		if err != nil {
+			return err
+		}
+
+		generated, err := scaffold.GenerateHandler(p, scaffold.HandlerOptions{
+			Name:   args[0],
+			Method: handlerFlags.method,
+			Path:   handlerFlags.path,
+			Force:  generateFlags.force,
+		})
+		if err != nil {
+			return generateError(err)
+		}
+		printGenerated(cmd.OutOrStdout(), generated)
+		return nil
+	},
+}
+
+var generateModelCmd = &cobra.Command{
+	Use:   "model <Name> [field:type[:modifier]...]",
+	Short: "Generate a model struct and its SQL table",
+	Long: `Generate a model struct in pkg/models with JSON and db tags. When the
+project has a database, the CREATE TABLE statement of the model is written
+in pkg/db/schema/<table>.sql, the table being the plural of the model name.
+
+Fields are written name:type, followed by modifiers:
+
+  null     the column accepts NULL, the field is a pointer
+  unique   the column has a UNIQUE constraint
+  pk       the field is the primary key, instead of the generated id
+
+Field types: ` + strings.Join(scaffold.FieldTypes(), ", ") + `.
+
+An id primary key is added unless a field is marked pk, an auto-incremented
+integer or, with --pk uuid, a UUID string. created_at and updated_at
+timestamps are added unless --timestamps=false.`,
+	Example: `  goginit generate model User name:string email:string:unique age:int
+  goginit g model Post title:string body:text published_at:time:null --pk uuid`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := openProject(cmd.Context(), generateFlags.dir, generateFlags.framework)
+		if err != nil {
+			return err
+		}
+
+		generated, err := scaffold.GenerateModel(p, scaffold.ModelOptions{
+			Name:         args[0],
+			Fields:       args[1:],
+			PrimaryKey:   modelFlags.primaryKey,
+			NoTimestamps: !modelFlags.timestamps,
+			Force:        generateFlags.force,
+		})
+		if err != nil {
+			return generateError(err)
+		}
+		printGenerated(cmd.OutOrStdout(), generated)
+		return nil
+	},
+}
+
+var generateResourceCmd = &cobra.Command{
+	Use:   "resource <Name> [field:type[:modifier]...]",
+	Short: "Generate a model with its repository, CRUD handlers and routes",
+	Long: `Generate everything serving a model over HTTP in a project with a database:
+the model and its table as generate model does, a repository in pkg/db, list,
+get, create, update and delete handlers in internal/handlers with a test
+running them against a temporary database, and their routes.
+
+The routes are added to routes.Register in internal/routes/routes.go, or
+printed when the project has no such function. Fields and flags are those of
+generate model.`,
+	Example: `  goginit generate resource User name:string email:string:unique age:int
+  goginit g resource Post title:string body:text --pk uuid`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := openProject(cmd.Context(), generateFlags.dir, generateFlags.framework)
+		if err != nil {
+			return err
+		}
+
+		generated, err := scaffold.GenerateResource(p, scaffold.ModelOptions{
+			Name:         args[0],
+			Fields:       args[1:],
+			PrimaryKey:   modelFlags.primaryKey,
+			NoTimestamps: !modelFlags.timestamps,
+			Force:        generateFlags.force,
+		})
+		if err != nil {
+			return generateError(err)
+		}
+		printGenerated(cmd.OutOrStdout(), generated)
+		return nil
+	},
+}
+
+var generateMiddlewareCmd = &cobra.Command{
+	Use:   "middleware [kind]",
+	Short: "Generate an HTTP middleware and attach it to the router",
+	Long: `Generate an HTTP middleware in internal/middleware, written in the idiom of
+the project's framework, and attach it in middleware.Register after the
+middleware already there. The middleware is printed when the project has no
+such function. Kinds:
+
+` + middlewareKindList() + `
+
+With --custom <Name>, an empty middleware is written instead, with the
+signature of the framework, to fill in.`,
+	Example: `  goginit generate middleware request-id
+  goginit g middleware cors
+  goginit g middleware --custom RateLimit`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := openProject(cmd.Context(), generateFlags.dir, generateFlags.framework)
+		if err != nil {
+			return err
+		}
+
+		opts := scaffold.MiddlewareOptions{Custom: middlewareFlags.custom, Force: generateFlags.force}
+		if len(args) > 0 {
+			opts.Kind = args[0]
+		}
+		generated, err := scaffold.GenerateMiddleware(p, opts)
+		if err != nil {
+			return generateError(err)
+		}
+		printGenerated(cmd.OutOrStdout(), generated)
+		return nil
+	},
+}
+
+var generateRouteCmd = &cobra.Command{
+	Use:   "route <path> <handler>",
+	Short: "Register a route in the project's router",
+	Long: `Register a route for an existing handler, in the idiom of the project's
+framework. The route is added to the Register function of
+internal/routes/routes.go, or with --file to another file, after the router
+is set up: r := gin.Default(), e := echo.New(), chi.NewRouter(),
+mux.NewRouter(), http.NewServeMux() or the http.HandleFunc calls of a main
+function.
+
+The file is edited through its syntax tree and formatted with gofmt, so it can
+hold any code. A route already registered is not added twice. The handler's
+package is imported, the project's internal/handlers for handlers.<Name>.`,
+	Example: `  goginit generate route /users/:id handlers.GetUser
+  goginit g route /orders handlers.CreateOrder -X POST --file cmd/api/main.go`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := openProject(cmd.Context(), generateFlags.dir, generateFlags.framework)
+		if err != nil {
+			return err
+		}
+
+		generated, err := scaffold.AddRoute(p, scaffold.RouteOptions{
+			Method:  routeFlags.method,
+			Path:    args[0],
+			Handler: args[1],
+			Import:  routeFlags.importPath,
+			File:    routeFlags.file,
+		})
+		if err != nil {
+			return err
+		}
+		if len(generated.Modified) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "The route is already registered")
+			return nil
+		}
+		printGenerated(cmd.OutOrStdout(), generated)
+		return nil
+	},
+}
+
+var generateOpenAPICmd = &cobra.Command{
+	Use:   "openapi <document>",
+	Short: "Generate models, handler stubs and routes from an OpenAPI 3 document",
+	Long: `Generate the server side of an OpenAPI 3 document, in YAML or JSON:
+
+  pkg/models/openapi.go        types of the component schemas and of the
+                               inline request and response bodies
+  internal/handlers/<op>.go    a handler stub per operation, binding the JSON
+                               request body and responding 501 Not Implemented
+  internal/routes/routes.go    the route of every operation
+
+Handlers are named after the operationId, or the method and path of
+operations without one. Run the command again when the document changes:
+pkg/models/openapi.go is rewritten, stubs are added for new operations, and
+operations whose handler is already declared in internal/handlers keep it, so
+implemented handlers are never overwritten. Routes already registered are not
+added twice.`,
+	Example: `  goginit generate openapi api.yaml
+  goginit g openapi docs/openapi.json -C services/pets`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := openProject(cmd.Context(), generateFlags.dir, generateFlags.framework)
+		if err != nil {
+			return err
+		}
+
+		generated, err := scaffold.GenerateOpenAPI(p, scaffold.OpenAPIOptions{
+			Spec:  args[0],
+			Force: generateFlags.force,
+		})
+		if err != nil {
+			return generateError(err)
+		}
+		printGenerated(cmd.OutOrStdout(), generated)
+		return nil
+	},
+}
+
+func init() {
+	generateCmd.AddCommand(generateHandlerCmd)
+	generateCmd.AddCommand(generateRouteCmd)
+	generateCmd.AddCommand(generateModelCmd)
+	generateCmd.AddCommand(generateResourceCmd)
+	generateCmd.AddCommand(generateOpenAPICmd)
+	generateCmd.AddCommand(generateMiddlewareCmd)
+
+	generateCmd.PersistentFlags().StringVarP(&generateFlags.dir, "dir", "C", ".", "Project directory, or any directory inside it")
+	generateCmd.PersistentFlags().StringVarP(&generateFlags.framework, "framework", "f", "", "Framework of the project, when it cannot be detected ("+strings.Join(config.FrameworkNames(), ", ")+")")
+	generateCmd.PersistentFlags().BoolVar(&generateFlags.force, "force", false, "Overwrite existing files")
+
+	generateHandlerCmd.Flags().StringVarP(&handlerFlags.method, "method", "X", "GET", "HTTP method ("+strings.Join(routing.Methods, ", ")+")")
+	generateHandlerCmd.Flags().StringVarP(&handlerFlags.path, "path", "p", "", "Route path, such as /users/:id (default: /<kebab-case name>)")
+
+	generateRouteCmd.Flags().StringVarP(&routeFlags.method, "method", "X", "GET", "HTTP method ("+strings.Join(routing.Methods, ", ")+")")
+	generateRouteCmd.Flags().StringVar(&routeFlags.file, "file", "", "File registering the routes, relative to the project (default: internal/routes/routes.go)")
+	generateRouteCmd.Flags().StringVar(&routeFlags.importPath, "import", "", "Import path of the handler's package")
+
+	generateModelCmd.Flags().StringVar(&modelFlags.primaryKey, "pk", scaffold.PrimaryKeyInt, "Type of the generated id primary key ("+scaffold.PrimaryKeyInt+" or "+scaffold.PrimaryKeyUUID+")")
+	generateModelCmd.Flags().BoolVar(&modelFlags.timestamps, "timestamps", true, "Add created_at and updated_at fields")
+	generateResourceCmd.Flags().AddFlagSet(generateModelCmd.Flags())
+
+	generateMiddlewareCmd.Flags().StringVar(&middlewareFlags.custom, "custom", "", "Name of a custom middleware to write as an empty skeleton")
+}
+
+// middlewareKindList returns the middleware of the catalog, one per line
+func middlewareKindList() string {
+	var lines []string
+	for _, kind := range scaffold.MiddlewareKinds() {
+		lines = append(lines, fmt.Sprintf("  %-12s %s", kind.Name, kind.Description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// openProject opens the project containing dir, with the given framework
+// when set
+func openProject(ctx context.Context, dir, framework string) (scaffold.Project, error) {
+	if framework != "" {
+		if _, err := config.GetFrameworkConfig(framework); err != nil {
+			return scaffold.Project{}, err
+		}
+	}
+
+	// A project is returned even when its framework cannot be detected
+	p, err := scaffold.OpenProject(ctx, newRunner(), dir)
+	if p.Dir == "" {
+		return p, err
+	}
+	if framework != "" {
+		p.Framework = framework
+		return p, nil
+	}
+	if err != nil {
+		return p, fmt.Errorf("%w, choose one with --framework", err)
+	}
+	return p, nil
+}
+
+// generateError adds the flag to use to an error of a generator
+func generateError(err error) error {
+	if errors.Is(err, scaffold.ErrExists) {
+		return fmt.Errorf("%w, overwrite it with --force", err)
+	}
+	return err
+}
+
+// printGenerated lists the written and changed files, the declarations
+// kept and the routes and middleware to register
+func printGenerated(w io.Writer, generated scaffold.Generated) {
+	for _, file := range generated.Files {
+		fmt.Fprintf(w, "Created %s\n", file)
+	}
+	for _, file := range generated.Modified {
+		fmt.Fprintf(w, "Updated %s\n", file)
+	}
+	for _, name := range generated.Kept {
+		fmt.Fprintf(w, "Kept %s, already declared\n", name)
+	}
+	if len(generated.Routes) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Register the routes in internal/routes/routes.go:")
+		fmt.Fprintln(w)
+		for _, route := range generated.Routes {
+			fmt.Fprintf(w, "\t%s\n", route)
+		}
+	}
+	if len(generated.Middleware) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Attach the middleware in internal/middleware/middleware.go:")
+		fmt.Fprintln(w)
+		for _, stmt := range generated.Middleware {
+			fmt.Fprintf(w, "\t%s\n", stmt)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/pol-cova/GoGinit/config"
+	"github.com/pol-cova/GoGinit/internal/openapi"
+	"github.com/pol-cova/GoGinit/scaffold"
+	"github.com/spf13/cobra"
+)
+
+// openAPIFlags holds the values passed to the openapi command
+var openAPIFlags struct {
+	dir       string
+	framework string
+	output    string
+	title     string
+	version   string
+	force     bool
+}
+
+var openAPICmd = &cobra.Command{
+	Use:   "openapi",
+	Short: "Write the OpenAPI 3 document of the project's API",
+	Long: `Derive the OpenAPI 3 document of the project's API from its sources and write
+it to api/openapi.yaml. The Go files are read without building them:
+
+  - every route listed by goginit routes is an operation, its path
+    parameters being required strings
+  - the handler of the route gives the query parameters it reads, the JSON
+    request body it binds and the responses it writes, with their status and
+    body type, following the helpers it calls
+  - the doc comment of the handler gives the summary and description of the
+    operation, the first paragraph being the summary
+  - the types of the bodies and all exported types of pkg/models are schema
+    components, following their json tags: fields without omitempty are
+    required, and the doc comments of types and fields are descriptions
+
+Routes matching every method are described for the methods their handler
+compares r.Method with, GET when it compares none. The document is rewritten
+by every run; a document written by hand is only overwritten with --force.`,
+	Example: `  goginit openapi
+  goginit openapi --title "Pet Store" --version 1.2.0
+  goginit openapi -o - | less`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := openProject(cmd.Context(), openAPIFlags.dir, openAPIFlags.framework)
+		if err != nil {
+			return err
+		}
+		info := openapi.Info{Title: openAPIFlags.title, Version: openAPIFlags.version}
+
+		if openAPIFlags.output == "-" {
+			doc, err := scaffold.DescribeAPI(p, info)
+			if err != nil {
+				return err
+			}
+			data, err := openapi.Marshal(doc)
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(data)
+			return err
+		}
+
+		generated, err := scaffold.ExportOpenAPI(p, scaffold.ExportOptions{
+			Info:   info,
+			Output: openAPIFlags.output,
+			Force:  openAPIFlags.force,
+		})
+		if err != nil {
+			return generateError(err)
+		}
+		printGenerated(cmd.OutOrStdout(), generated)
+		return nil
+	},
+}
+
+func init() {
+	openAPICmd.Flags().StringVarP(&openAPIFlags.dir, "dir", "C", ".", "Project directory, or any directory inside it")
+	openAPICmd.Flags().StringVarP(&openAPIFlags.framework, "framework", "f", "", "Framework of the project, when it cannot be detected ("+strings.Join(config.FrameworkNames(), ", ")+")")
+	openAPICmd.Flags().StringVarP(&openAPIFlags.output, "output", "o", scaffold.OpenAPIDocument, "Path of the document, relative to the project, or - for stdout")
+	openAPICmd.Flags().StringVar(&openAPIFlags.title, "title", "", "Title of the API (default: the project name)")
+	openAPICmd.Flags().StringVar(&openAPIFlags.version, "version", "0.1.0", "Version of the API")
+	openAPICmd.Flags().BoolVar(&openAPIFlags.force, "force", false, "Overwrite a document that was not written by goginit")
+}
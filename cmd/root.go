@@ -1,15 +1,20 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"github.com/pol-cova/GoGinit/internal/db"
-	"github.com/pol-cova/GoGinit/templates"
 	"os"
-	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
 
+	"github.com/mattn/go-isatty"
 	"github.com/pol-cova/GoGinit/config"
 	"github.com/pol-cova/GoGinit/internal/tui"
+	"github.com/pol-cova/GoGinit/runner"
+	"github.com/pol-cova/GoGinit/scaffold"
 	"github.com/spf13/cobra"
 )
 
@@ -23,61 +28,298 @@ var rootCmd = &cobra.Command{
 }
 
 func Execute() error {
-	return rootCmd.Execute()
+	// Ctrl+C cancels the running go commands and is handled by the commands,
+	// init for instance removes its partially generated project
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return rootCmd.ExecuteContext(ctx)
+}
+
+// verbose makes the go commands run by goginit print what they run
+var verbose bool
+
+// newRunner returns the runner used for the go commands, logging every
+// command to stderr with --verbose
+func newRunner() *runner.Exec {
+	r := &runner.Exec{}
+	if verbose {
+		r.Log = os.Stderr
+	}
+	return r
 }
 
 func init() {
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Print every command run, with its environment overrides and duration")
+
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(startCmd)
 	rootCmd.AddCommand(cleanCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(generateCmd)
+	rootCmd.AddCommand(routesCmd)
+	rootCmd.AddCommand(openAPICmd)
+
+	initCmd.Flags().StringVarP(&initFlags.name, "name", "n", "", "Project name")
+	initCmd.Flags().StringVarP(&initFlags.module, "module", "m", "", "Go module path (default: <modulePrefix>/<name>, or <name> without a prefix)")
+	initCmd.Flags().StringVar(&initFlags.binary, "binary", "", "Name of the cmd/<binary> main package (default: <name>)")
+	initCmd.Flags().StringVarP(&initFlags.framework, "framework", "f", "", "Framework to use ("+strings.Join(config.FrameworkNames(), ", ")+")")
+	initCmd.Flags().StringVar(&initFlags.frameworkVersion, "framework-version", "", "Framework version to go get instead of the pinned default (e.g. v1.10.0 or latest)")
+	initCmd.Flags().StringVar(&initFlags.goVersion, "go-version", "", "Go version written as the go directive of go.mod (e.g. 1.22 or 1.22.3)")
+	initCmd.Flags().StringVar(&initFlags.toolchain, "toolchain", "", "Toolchain written as the toolchain directive of go.mod, with --go-version (e.g. go1.22.3)")
+	initCmd.Flags().BoolVar(&initFlags.db, "db", false, "Set up a SQLite database")
+	initCmd.Flags().BoolVarP(&initFlags.yes, "yes", "y", false, "Never launch the wizard, use defaults for missing values")
+	initCmd.Flags().StringVarP(&initFlags.template, "template", "t", "", "User template: a directory path, or a name in ~/.config/goginit/templates")
+	initCmd.Flags().StringVar(&initFlags.from, "from", "", "Read the project spec from a goginit.yaml or JSON file instead of running the wizard")
+	initCmd.Flags().StringVar(&initFlags.openAPI, "openapi", "", "Generate models, handler stubs and routes from an OpenAPI 3 document")
+	initCmd.Flags().BoolVar(&initFlags.dryRun, "dry-run", false, "Print the files and commands that would be created and run, without touching the disk")
+	initCmd.Flags().BoolVar(&initFlags.showContents, "show-contents", false, "With --dry-run, also print the content of every file")
+	initCmd.Flags().BoolVar(&initFlags.noVerify, "no-verify", false, "Skip running go build and go vet in the generated project")
+	initCmd.Flags().BoolVar(&initFlags.offline, "offline", false, "Resolve dependencies from the local module cache only, without network access")
+	initCmd.Flags().StringVar(&initFlags.proxyDir, "proxy-dir", "", "Resolve dependencies from a file-based GOPROXY directory (implies --offline)")
+}
+
+// initFlags holds the values passed to the init command
+var initFlags struct {
+	name             string
+	module           string
+	binary           string
+	framework        string
+	frameworkVersion string
+	goVersion        string
+	toolchain        string
+	db               bool
+	template         string
+	yes              bool
+	from             string
+	openAPI          string
+
+	dryRun       bool
+	showContents bool
+	noVerify     bool
+
+	offline  bool
+	proxyDir string
 }
 
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize a new Go project",
-	Long:  `This command will initialize a new Go backend project with a base template and allow you to choose a framework.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// Banner
-		fmt.Println(`
+	Long: `This command will initialize a new Go backend project with a base template and allow you to choose a framework.
+
+Values given as flags are not asked for again. With --yes, --from, or when stdin
+is not a terminal, the wizard is never launched: the framework defaults to
+"default", no database is set up, and a project name is required.
+
+The module path defaults to the project name, prefixed with the modulePrefix set
+in the user config file (~/.config/goginit/config.yaml on Linux) or the
+GOGINIT_MODULE_PREFIX environment variable.
+
+With --template, the project layout comes from a user template directory
+instead of the built-in one. File names and contents are rendered with
+text/template, with the variables .ProjectName, .ModulePath, .Binary,
+.Framework, .FrameworkImport, .Database and .GoVersion. A .tmpl suffix is
+removed from file names.
+
+With --go-version, go.mod targets that Go version instead of the installed
+one, templates avoid newer features, and frameworks needing a newer Go are
+refused.
+
+With --openapi, the models, handler stubs and routes of an OpenAPI 3 document
+are generated in the new project, as goginit generate openapi does.
+
+Flags given together with --from override the values in the spec file. The
+final choices are recorded as goginit.yaml in the generated project.`,
+	Example: `  goginit init
+  goginit init --name myservice --framework gin --db
+  goginit init -n myservice -f echo -y
+  goginit init -n myservice -m github.com/ourorg/myservice -f gin -y
+  goginit init --from goginit.yaml
+  goginit init -n petstore -f chi --openapi api.yaml -y
+  goginit init -n myservice -f chi --dry-run --show-contents
+  goginit init -n myservice -f chi --go-version 1.23 --toolchain go1.23.4`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		spec, err := initSpecFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		// Fail fast on bad values, before the wizard or any disk access
+		if err := spec.Validate(); err != nil {
+			return err
+		}
+		if spec.Name != "" {
+			if err := config.CheckProjectDir(spec.Name); err != nil {
+				return err
+			}
+		}
+		if spec.Template != "" {
+			if _, err := config.ResolveTemplateDir(spec.Template); err != nil {
+				return err
+			}
+		}
+		userConfig, err := config.LoadUserConfig()
+		if err != nil {
+			return err
+		}
+
+		dbAnswered := initFlags.from != "" || cmd.Flags().Changed("db")
+		complete := spec.Name != "" && spec.Framework != "" && dbAnswered
+		interactive := !initFlags.yes && initFlags.from == "" && isatty.IsTerminal(os.Stdin.Fd())
+
+		if !complete && interactive {
+			printBanner()
+
+			// Call the TUI to get the missing user input
+			answers, err := tui.GetUserInput(tui.Answers{
+				ProjectName:  spec.Name,
+				ModulePath:   spec.Module,
+				ModulePrefix: userConfig.ModulePrefix,
+				Framework:    spec.Framework,
+				SetupDB:      spec.SetupDB(),
+				DBAnswered:   dbAnswered,
+			})
+			if err != nil {
+				return err
+			}
+			if !answers.Complete() {
+				return errors.New("init aborted")
+			}
+			spec.Name = answers.ProjectName
+			spec.Module = answers.ModulePath
+			spec.Framework = answers.Framework
+			spec.Database = config.DatabaseNone
+			if answers.SetupDB {
+				spec.Database = config.DatabaseSQLite
+			}
+		} else if spec.Framework == "" {
+			spec.Framework = "default"
+		}
+
+		if spec.Name == "" || spec.Framework == "" {
+			if !interactive {
+				return errors.New("project name is required, pass it with --name or in the spec file")
+			}
+			return errors.New("project name or framework not selected")
+		}
+		if spec.Module == "" {
+			spec.Module = config.DefaultModulePath(userConfig.ModulePrefix, spec.Name)
+		}
+		// The wizard and the defaults may have filled in new values
+		if err := spec.Validate(); err != nil {
+			return err
+		}
+		opts := scaffold.Options{
+			Spec:       spec,
+			Runner:     newRunner(),
+			Offline:    initFlags.offline,
+			ProxyDir:   initFlags.proxyDir,
+			SkipVerify: initFlags.noVerify,
+			OpenAPI:    initFlags.openAPI,
+			Hooks:      userConfig.Hooks,
+			Output:     cmd.OutOrStdout(),
+		}
+		if initFlags.from != "" {
+			opts.SpecDir = filepath.Dir(initFlags.from)
+		}
+		if initFlags.dryRun {
+			return printDryRun(cmd.Context(), cmd.OutOrStdout(), opts, initFlags.showContents)
+		}
+
+		// Create the project skeleton and handle any additional setup
+		_, err = scaffold.Generate(cmd.Context(), opts)
+		return err
+	},
+}
+
+// initSpecFromFlags builds the project spec from the --from file, if any,
+// overridden by the flags explicitly set on the command line
+func initSpecFromFlags(cmd *cobra.Command) (config.ProjectSpec, error) {
+	var spec config.ProjectSpec
+	if initFlags.from != "" {
+		var err error
+		if spec, err = config.LoadSpec(initFlags.from); err != nil {
+			return spec, err
+		}
+	}
+
+	if initFlags.name != "" {
+		spec.Name = initFlags.name
+	}
+	if initFlags.module != "" {
+		spec.Module = initFlags.module
+	}
+	if initFlags.binary != "" {
+		spec.Binary = initFlags.binary
+	}
+	if initFlags.framework != "" {
+		spec.Framework = initFlags.framework
+	}
+	if initFlags.template != "" {
+		spec.Template = initFlags.template
+	}
+	if initFlags.frameworkVersion != "" {
+		spec.FrameworkVersion = initFlags.frameworkVersion
+	}
+	if initFlags.goVersion != "" {
+		spec.GoVersion = initFlags.goVersion
+	}
+	if initFlags.toolchain != "" {
+		spec.Toolchain = initFlags.toolchain
+	}
+	if cmd.Flags().Changed("db") {
+		spec.Database = config.DatabaseNone
+		if initFlags.db {
+			spec.Database = config.DatabaseSQLite
+		}
+	}
+	return spec, nil
+}
+
+// printBanner prints the GoGinit welcome banner
+func printBanner() {
+	// Banner
+	fmt.Println(`
    ____              ____   _           _   _   
   / ___|   ___      / ___| (_)  _ __   (_) | |_ 
  | |  _   / _ \    | |  _  | | | '_ \  | | | __|
  | |_| | | (_) |   | |_| | | | | | | | | | | |_ 
   \____|  \___/     \____| |_| |_| |_| |_|  \__|
 			`)
-		fmt.Println("Welcome to GoGinit! Let's initialize a new Go project.")
-
-		// Call the TUI to get user input
-		projectName, framework, setupDB := tui.GetUserInput()
-		if projectName == "" || framework == "" {
-			fmt.Println("Project name or framework not selected.")
-			return
-		}
-		// Create the project skeleton and handle any additional setup
-		createProjectSkeleton(projectName, framework, setupDB)
-	},
+	fmt.Println("Welcome to GoGinit! Let's initialize a new Go project.")
 }
 
 // Start dev server command
 var startCmd = &cobra.Command{
-	Use:   "start [projectName]",
+	Use:   "start [binaryName]",
 	Short: "Start the backend server",
-	Long:  `This command will run the main.go file located in cmd/projectName/main.go`,
-	Args:  cobra.ExactArgs(1), // Ensure exactly one argument is provided
-	Run: func(cmd *cobra.Command, args []string) {
-		projectName := args[0] // Get the project name from arguments
-		runMain(projectName)
+	Long: `This command will run the main.go file located in cmd/binaryName/main.go.
+
+Without an argument, the binary name recorded in goginit.yaml is used.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 {
+			runMain(cmd.Context(), newRunner(), args[0]) // Get the binary name from arguments
+			return nil
+		}
+
+		spec, err := config.LoadSpec(config.SpecFileName)
+		if err != nil {
+			return fmt.Errorf("no binary name given and %w", err)
+		}
+		runMain(cmd.Context(), newRunner(), spec.BinaryName())
+		return nil
 	},
 }
 
-func runMain(projectName string) {
-	mainPath := filepath.Join("cmd", projectName, "main.go")
-	cmd := exec.Command("go", "run", mainPath)
+func runMain(ctx context.Context, r runner.Runner, binaryName string) {
+	mainPath := filepath.Join("cmd", binaryName, "main.go")
+	cmd := runner.Go("", "run", mainPath)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
 	fmt.Printf("Running server: %s\n", mainPath)
-	if err := cmd.Run(); err != nil {
+	if _, err := r.Run(ctx, cmd); err != nil {
 		fmt.Printf("Failed to run server: %v\n", err)
 	}
 }
@@ -88,116 +330,17 @@ var cleanCmd = &cobra.Command{
 	Short: "Clean up the project dependencies",
 	Long:  `This command will clean up the project dependencies by running go mod tidy.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		cleanDependencies()
+		cleanDependencies(cmd.Context(), newRunner())
 	},
 }
 
-func cleanDependencies() {
-	cmd := exec.Command("go", "mod", "tidy")
+func cleanDependencies(ctx context.Context, r runner.Runner) {
+	cmd := runner.Go("", "mod", "tidy")
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
 	fmt.Println("Cleaning up dependencies...")
-	if err := cmd.Run(); err != nil {
+	if _, err := r.Run(ctx, cmd); err != nil {
 		fmt.Printf("Failed to clean up dependencies: %v\n", err)
 	}
 }
-
-func createProjectSkeleton(projectName, framework string, setupDB bool) {
-	// Create the necessary directories
-	dirs := []string{
-		filepath.Join(projectName, "cmd", projectName),
-		filepath.Join(projectName, "internal", "handlers"),
-		filepath.Join(projectName, "internal", "middleware"),
-		filepath.Join(projectName, "internal", "routes"),
-		filepath.Join(projectName, "pkg", "models"),
-		filepath.Join(projectName, "pkg", "db"),
-	}
-
-	for _, dir := range dirs {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			fmt.Println("Error creating directory:", err)
-			return
-		}
-	}
-
-	// Create empty files with comments
-	files := map[string]string{
-		filepath.Join(projectName, "internal", "handlers", "handlers.go"):     "// handlers package\npackage handlers",
-		filepath.Join(projectName, "internal", "middleware", "middleware.go"): "// middleware package\npackage middleware",
-		filepath.Join(projectName, "internal", "routes", "routes.go"):         "// routes package\npackage routes",
-		filepath.Join(projectName, "pkg", "models", "models.go"):              "// models package\npackage models",
-	}
-
-	// Conditionally add the db.go file
-	if setupDB {
-		files[filepath.Join(projectName, "pkg", "db", "db.go")] = "// db package\npackage db"
-	}
-
-	for path, content := range files {
-		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
-			fmt.Println("Error creating file:", err)
-			return
-		}
-	}
-
-	// Initialize Go module
-	if err := config.GenerateGoMod(projectName); err != nil {
-		fmt.Println("Error generating go.mod file:", err)
-		return
-	}
-
-	frameworkConfig, err := config.GetFrameworkConfig(framework)
-	if err != nil {
-		fmt.Println("Error getting framework configuration:", err)
-		return
-	}
-	fmt.Println("Successfully retrieved framework configuration:", frameworkConfig.Name)
-
-	if err := config.FetchFrameworkDependencies(projectName, frameworkConfig.Name); err != nil {
-		fmt.Println("Error getting framework dependencies:", err)
-		return
-	}
-	fmt.Println("Successfully fetched framework dependencies for:", frameworkConfig.Name)
-
-	var mainFileContent string
-
-	// Select the appropriate template
-	switch framework {
-	case "echo":
-		mainFileContent = templates.EchoTemplate
-	case "gin":
-		mainFileContent = templates.GinTemplate
-	case "fiber":
-		mainFileContent = templates.FiberTemplate
-	case "martini":
-		mainFileContent = templates.MartiniTemplate
-	case "chi":
-		mainFileContent = templates.ChiTemplate
-	case "mux":
-		mainFileContent = templates.MuxTemplate
-	case "gofr":
-		mainFileContent = templates.GoFrTemplate
-	case "fuego":
-		mainFileContent = templates.FuegoTemplate
-	case "default":
-		mainFileContent = templates.DefaultTemplate
-	default:
-		fmt.Println("Invalid framework selected.")
-		return
-	}
-
-	// Create the main.go file in the appropriate directory
-	mainFilePath := filepath.Join(projectName, "cmd", projectName, "main.go")
-	if err := os.WriteFile(mainFilePath, []byte(mainFileContent), 0644); err != nil {
-		fmt.Println("Error creating main.go file:", err)
-		return
-	}
-
-	// Setup the database if required
-	if setupDB {
-		db.SetupDatabase(projectName, setupDB)
-	}
-
-	fmt.Println("Project created successfully, Happy Coding! 🎉")
-}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pol-cova/GoGinit/config"
+	"github.com/pol-cova/GoGinit/internal/routing"
+	"github.com/pol-cova/GoGinit/scaffold"
+	"github.com/spf13/cobra"
+)
+
+// routesFlags holds the values passed to the routes command
+var routesFlags struct {
+	dir       string
+	framework string
+	json      bool
+}
+
+var routesCmd = &cobra.Command{
+	Use:   "routes",
+	Short: "List the routes registered by the project",
+	Long: `List the routes registered by the project, with their method, path, handler
+and source location. The Go files of the module are read without building
+them, and registrations are recognized in the idiom of the project's
+framework, following router groups such as r.Group("/api").
+
+Routes matching every method are listed with the ANY method.
+
+Files are read one at a time, from their syntax only, without type checking
+the module, so the listing is best effort: a router kept in a struct field,
+returned by a function of the project or held in a variable of a type of
+the project is not followed, and the routes of a helper function are
+listed without the prefix of the group it is called with.`,
+	Example: `  goginit routes
+  goginit routes --json | jq '.[] | select(.method == "GET")'`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := openProject(cmd.Context(), routesFlags.dir, routesFlags.framework)
+		if err != nil {
+			return err
+		}
+		routes, err := scaffold.ListRoutes(p)
+		if err != nil {
+			return err
+		}
+
+		w := cmd.OutOrStdout()
+		if routesFlags.json {
+			if routes == nil {
+				routes = []routing.Route{}
+			}
+			out, err := json.MarshalIndent(routes, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(w, string(out))
+			return nil
+		}
+
+		if len(routes) == 0 {
+			fmt.Fprintln(w, "No routes found")
+			return nil
+		}
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "METHOD\tPATH\tHANDLER\tLOCATION")
+		for _, r := range routes {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s:%d\n", r.Method, r.Path, r.Handler, r.File, r.Line)
+		}
+		return tw.Flush()
+	},
+}
+
+func init() {
+	routesCmd.Flags().StringVarP(&routesFlags.dir, "dir", "C", ".", "Project directory, or any directory inside it")
+	routesCmd.Flags().StringVarP(&routesFlags.framework, "framework", "f", "", "Framework of the project, when it cannot be detected ("+strings.Join(config.FrameworkNames(), ", ")+")")
+	routesCmd.Flags().BoolVar(&routesFlags.json, "json", false, "Print the routes as a JSON array")
+}
@@ -1,48 +1,24 @@
 package config
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"github.com/pol-cova/GoGinit/templates"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
-)
-
-type FrameworkConfig struct {
-	Name     string
-	Template string
-}
-
-// GetFrameworkConfig returns the configuration for the specified framework
-func GetFrameworkConfig(framework string) (FrameworkConfig, error) {
-	configs := map[string]FrameworkConfig{
-		"echo":    {"github.com/labstack/echo/v4", templates.EchoTemplate},
-		"gin":     {"github.com/gin-gonic/gin", templates.GinTemplate},
-		"fiber":   {"github.com/gofiber/fiber/v3", templates.FiberTemplate},
-		"martini": {"github.com/go-martini/martini", templates.MartiniTemplate},
-		"chi":     {"github.com/go-chi/chi/v5", templates.ChiTemplate},
-		"mux":     {"github.com/gorilla/mux", templates.MuxTemplate},
-		"gofr":    {"github.com/gofr-dev/gofr", templates.GoFrTemplate},
-		"fuego":   {"github.com/go-fuego/fuego", templates.FuegoTemplate},
-		"default": {"", templates.DefaultTemplate},
-	}
-
-	config, ok := configs[framework]
-	if !ok {
-		return FrameworkConfig{}, fmt.Errorf("unknown framework: %s", framework)
-	}
 
-	return config, nil
-}
+	"github.com/pol-cova/GoGinit/runner"
+)
 
 // GetGoVersion fetches the current Go version
-func GetGoVersion() (string, error) {
-	cmd := exec.Command("go", "version")
-	output, err := cmd.Output()
+func GetGoVersion(ctx context.Context, r runner.Runner) (string, error) {
+	result, err := r.Run(ctx, runner.Go("", "version"))
 	if err != nil {
 		return "", err
 	}
+	output := result.Output
 
 	parts := strings.Fields(string(output))
 	if len(parts) < 3 {
@@ -53,52 +29,104 @@ func GetGoVersion() (string, error) {
 	return version, nil
 }
 
-// GenerateGoMod initializes the Go module using `go mod init`
-// GenerateGoMod initializes the Go module using `go mod init`
-// if it hasn't already been initialized.
-func GenerateGoMod(moduleName string) error {
-	if moduleName == "" {
-		return fmt.Errorf("moduleName cannot be empty")
+// GenerateGoMod initializes the Go module in projectDir using `go mod init`
+// if it hasn't already been initialized. Progress is written to w.
+func GenerateGoMod(ctx context.Context, r runner.Runner, w io.Writer, projectDir, moduleName string) error {
+	if projectDir == "" || moduleName == "" {
+		return fmt.Errorf("projectDir and moduleName cannot be empty")
 	}
 
 	// Check if go.mod already exists
-	goModPath := filepath.Join(moduleName, "go.mod")
+	goModPath := filepath.Join(projectDir, "go.mod")
 	if _, err := os.Stat(goModPath); !os.IsNotExist(err) {
 		// go.mod already exists
-		fmt.Printf("go.mod already exists in %s, skipping initialization\n", moduleName)
+		fmt.Fprintf(w, "go.mod already exists in %s, skipping initialization\n", projectDir)
 		return nil
 	}
 
 	// Initialize the Go module
-	cmd := exec.Command("go", "mod", "init", moduleName)
-	cmd.Dir = moduleName
-	output, err := cmd.CombinedOutput()
+	result, err := r.Run(ctx, runner.Go(projectDir, "mod", "init", moduleName))
 	if err != nil {
-		return fmt.Errorf("error initializing Go module: %v, Output: %s", err, output)
+		return fmt.Errorf("error initializing Go module: %v, Output: %s", err, result.Output)
 	}
 
 	// Optionally, fetch the Go version (though `go mod init` handles it automatically)
-	goVersion, err := GetGoVersion()
+	goVersion, err := GetGoVersion(ctx, r)
 	if err != nil {
 		return fmt.Errorf("error getting Go version: %v", err)
 	}
-	fmt.Printf("Initialized Go module %s with Go version %s\n", moduleName, goVersion)
+	fmt.Fprintf(w, "Initialized Go module %s with Go version %s\n", moduleName, goVersion)
 
 	return nil
 }
 
-// FetchFrameworkDependencies fetches the latest version of the framework dependencies
-func FetchFrameworkDependencies(moduleName, framework string) error {
-	if moduleName == "" {
-		return fmt.Errorf("moduleName cannot be empty")
+// FetchFrameworkDependencies fetches the framework dependencies into the
+// project in projectDir. dependency is a go get argument, as returned by
+// FrameworkConfig.Dependency.
+func FetchFrameworkDependencies(ctx context.Context, r runner.Runner, projectDir, dependency string) error {
+	if projectDir == "" {
+		return fmt.Errorf("projectDir cannot be empty")
+	}
+
+	result, err := r.Run(ctx, runner.Go(projectDir, "get", dependency))
+	if err != nil {
+		return fmt.Errorf("error fetching framework dependencies: %v, Output: %s", err, result.Output)
 	}
+	return nil
+}
 
-	// Change the directory to the project directory
-	cmd := exec.Command("go", "get", framework)
-	cmd.Dir = moduleName
-	output, err := cmd.CombinedOutput()
+// SetGoDirectives writes the go directive of the go.mod in projectDir and
+// its toolchain directive, removing it when toolchain is empty
+func SetGoDirectives(ctx context.Context, r runner.Runner, projectDir, goVersion, toolchain string) error {
+	result, err := r.Run(ctx, GoDirectivesCommand(projectDir, goVersion, toolchain))
 	if err != nil {
-		return fmt.Errorf("error fetching framework dependencies: %v, Output: %s", err, output)
+		return fmt.Errorf("error setting the Go version: %v, Output: %s", err, result.Output)
 	}
 	return nil
 }
+
+// GoDirectivesCommand returns the go mod edit command run by SetGoDirectives
+func GoDirectivesCommand(projectDir, goVersion, toolchain string) runner.Command {
+	if toolchain == "" {
+		toolchain = "none"
+	}
+	return runner.Go(projectDir, "mod", "edit", "-go="+goVersion, "-toolchain="+toolchain)
+}
+
+// GoDirective returns the go directive of the go.mod in projectDir
+func GoDirective(ctx context.Context, r runner.Runner, projectDir string) (string, error) {
+	mod, err := ReadGoMod(ctx, r, projectDir)
+	if err != nil {
+		return "", err
+	}
+	return mod.Go, nil
+}
+
+// GoMod is the content of a go.mod file, as printed by go mod edit -json
+type GoMod struct {
+	Module struct {
+		Path string
+	}
+	Go      string
+	Require []Requirement
+}
+
+// Requirement is a require directive of a go.mod file
+type Requirement struct {
+	Path     string
+	Version  string
+	Indirect bool
+}
+
+// ReadGoMod reads the go.mod in projectDir
+func ReadGoMod(ctx context.Context, r runner.Runner, projectDir string) (GoMod, error) {
+	var mod GoMod
+	result, err := r.Run(ctx, runner.Go(projectDir, "mod", "edit", "-json"))
+	if err != nil {
+		return mod, fmt.Errorf("error reading go.mod: %v, Output: %s", err, result.Output)
+	}
+	if err := json.Unmarshal(result.Output, &mod); err != nil {
+		return mod, fmt.Errorf("error reading go.mod: %w", err)
+	}
+	return mod, nil
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/pol-cova/GoGinit/templates"
+)
+
+// FrameworkConfig describes a supported framework. Every framework is
+// registered once and the wizard, validation, templates and dependency
+// fetching all read from the registry.
+type FrameworkConfig struct {
+	Name       string // Key used on the command line, in the wizard and in spec files
+	ImportPath string // Package fetched with go get, empty for the standard library
+	Version    string // Version pinned with go get, matching the template code
+
+	// MinGoVersion is the go directive the pinned version raises go.mod to,
+	// empty when it has no requirement of its own or when it is unknown
+	MinGoVersion string
+
+	Templates   fs.FS  // Template tree rendered into the project, see templates.Render
+	Description string // One line shown in the wizard
+	Color       string // Wizard color, as an ANSI color number
+}
+
+// Dependency returns the go get argument for the framework, including the
+// version when one is set. An empty version fetches the latest release.
+func (f FrameworkConfig) Dependency() string {
+	if f.Version == "" {
+		return f.ImportPath
+	}
+	return f.ImportPath + "@" + f.Version
+}
+
+// frameworks is the framework registry, in the order shown in the wizard.
+// The minimum Go version of gofr is not known, the go directive check after
+// go get still catches it.
+var frameworks = []FrameworkConfig{
+	{"echo", "github.com/labstack/echo/v4", "v4.16.0", "1.25.0", templates.Framework("echo"), "High performance, minimalist web framework", "4"},
+	{"gin", "github.com/gin-gonic/gin", "v1.12.0", "1.25.0", templates.Framework("gin"), "Martini-like API with much better performance", "2"},
+	{"fiber", "github.com/gofiber/fiber/v3", "v3.1.0", "1.25.0", templates.Framework("fiber"), "Express inspired framework built on Fasthttp", "3"},
+	{"martini", "github.com/go-martini/martini", "v0.0.0-20170121215854-22fa46961aab", "", templates.Framework("martini"), "Classic modular web framework", "6"},
+	{"chi", "github.com/go-chi/chi/v5", "v5.3.2", "1.23", templates.Framework("chi"), "Lightweight, idiomatic router for net/http", "5"},
+	{"mux", "github.com/gorilla/mux", "v1.8.1", "", templates.Framework("mux"), "Powerful URL router and dispatcher", "13"},
+	{"gofr", "gofr.dev/pkg/gofr", "v1.54.0", "", templates.Framework("gofr"), "Opinionated microservice framework", "9"},
+	{"fuego", "github.com/go-fuego/fuego", "v0.17.0", "1.23.3", templates.Framework("fuego"), "Framework generating OpenAPI from code", "9"},
+	{"default", "", "", "", templates.Framework("default"), "Native net/http, no dependencies", "8"},
+}
+
+// The SQLite driver fetched for the sqlite database, pinned like the
+// frameworks so that scaffolds with a database are reproducible
+const (
+	SQLiteImportPath = "github.com/mattn/go-sqlite3"
+	SQLiteVersion    = "v1.14.52"
+)
+
+// MinGoVersionSQLite is the go directive the pinned SQLite driver raises
+// go.mod to, the go directive of its own go.mod
+const MinGoVersionSQLite = "1.21"
+
+// SQLiteDependency returns the go get argument for the SQLite driver
+func SQLiteDependency() string {
+	return SQLiteImportPath + "@" + SQLiteVersion
+}
+
+// RegisterFramework adds a framework to the registry. It fails if the name
+// is empty or already registered.
+func RegisterFramework(f FrameworkConfig) error {
+	if f.Name == "" {
+		return fmt.Errorf("framework name cannot be empty")
+	}
+	if _, ok := lookupFramework(f.Name); ok {
+		return fmt.Errorf("framework %s is already registered", f.Name)
+	}
+	frameworks = append(frameworks, f)
+	return nil
+}
+
+// Frameworks returns every registered framework, in registration order
+func Frameworks() []FrameworkConfig {
+	return append([]FrameworkConfig(nil), frameworks...)
+}
+
+// FrameworkNames returns the names of every registered framework
+func FrameworkNames() []string {
+	names := make([]string, len(frameworks))
+	for i, f := range frameworks {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// GetFrameworkConfig returns the configuration for the specified framework
+func GetFrameworkConfig(framework string) (FrameworkConfig, error) {
+	config, ok := lookupFramework(framework)
+	if !ok {
+		return FrameworkConfig{}, fmt.Errorf("unknown framework: %s (available: %s)", framework, strings.Join(FrameworkNames(), ", "))
+	}
+
+	return config, nil
+}
+
+// lookupFramework finds a registered framework by name
+func lookupFramework(name string) (FrameworkConfig, bool) {
+	for _, f := range frameworks {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return FrameworkConfig{}, false
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Hooks are commands run around the generation of a project: Pre hooks run
+// before anything is created, Post hooks once the project is in place.
+// Hooks run in order and the first failure aborts the run.
+type Hooks struct {
+	Pre  []Hook `yaml:"pre,omitempty" json:"pre,omitempty"`
+	Post []Hook `yaml:"post,omitempty" json:"post,omitempty"`
+}
+
+// Hook is a shell command, or a script file, rendered with text/template
+// before it runs. A plain string in YAML or JSON is a Run command.
+type Hook struct {
+	Run    string `yaml:"run,omitempty" json:"run,omitempty"`       // Shell command line
+	Script string `yaml:"script,omitempty" json:"script,omitempty"` // Path of a script template, run with the shell
+}
+
+// UnmarshalYAML accepts a plain string as a Run command
+func (h *Hook) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		return value.Decode(&h.Run)
+	}
+
+	type plain Hook
+	if err := value.Decode((*plain)(h)); err != nil {
+		return err
+	}
+	return h.Validate()
+}
+
+// MarshalYAML writes a Run command as a plain string
+func (h Hook) MarshalYAML() (interface{}, error) {
+	if h.Script == "" {
+		return h.Run, nil
+	}
+	type plain Hook
+	return plain(h), nil
+}
+
+// Validate checks that exactly one of Run and Script is set
+func (h Hook) Validate() error {
+	if (h.Run == "") == (h.Script == "") {
+		return errors.New("a hook needs exactly one of run and script")
+	}
+	return nil
+}
+
+// String describes the hook in messages
+func (h Hook) String() string {
+	if h.Script != "" {
+		return "script " + h.Script
+	}
+	return h.Run
+}
+
+// Append returns the hooks of h followed by the hooks of other
+func (h Hooks) Append(other Hooks) Hooks {
+	return Hooks{
+		Pre:  append(append([]Hook(nil), h.Pre...), other.Pre...),
+		Post: append(append([]Hook(nil), h.Post...), other.Post...),
+	}
+}
+
+// resolveScripts makes the relative script paths of the hooks relative to dir
+func (h Hooks) resolveScripts(dir string) {
+	for _, hooks := range [][]Hook{h.Pre, h.Post} {
+		for i := range hooks {
+			if hooks[i].Script != "" && !filepath.IsAbs(hooks[i].Script) {
+				hooks[i].Script = filepath.Join(dir, hooks[i].Script)
+			}
+		}
+	}
+}
+
+// validate checks every hook
+func (h Hooks) validate() error {
+	for i, hook := range h.Pre {
+		if err := hook.Validate(); err != nil {
+			return fmt.Errorf("invalid pre hook %d: %w", i+1, err)
+		}
+	}
+	for i, hook := range h.Post {
+		if err := hook.Validate(); err != nil {
+			return fmt.Errorf("invalid post hook %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/pol-cova/GoGinit/runner"
+)
+
+// MissingModulesError lists the modules an offline run could not resolve
+type MissingModulesError struct {
+	Modules []string // module@version, or import path@version when the module is unknown
+}
+
+func (e *MissingModulesError) Error() string {
+	return fmt.Sprintf("offline mode: modules missing from the module cache:\n  %s\nrun `goginit cache warm` while online to download them",
+		strings.Join(e.Modules, "\n  "))
+}
+
+// OfflineEnv returns the environment overrides that make go commands resolve
+// modules without network access: from a file-based GOPROXY directory when
+// proxyDir is set, or else from the download cache of the local module cache
+// used as one, so that the pinned versions it holds resolve. Checksum
+// database lookups are disabled as they need the network too; go.sum still
+// records and checks the hashes of the cached modules.
+func OfflineEnv(ctx context.Context, r runner.Runner, proxyDir string) ([]string, error) {
+	dir := proxyDir
+	if dir == "" {
+		modCache, err := goEnv(ctx, r, "GOMODCACHE")
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(modCache, "cache", "download")
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving proxy directory: %w", err)
+	}
+	if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+		if proxyDir == "" {
+			return nil, fmt.Errorf("module cache %s does not exist, run `goginit cache warm` while online", dir)
+		}
+		return nil, fmt.Errorf("proxy directory %s does not exist", proxyDir)
+	}
+	proxy := "file://" + filepath.ToSlash(abs)
+	if !strings.HasPrefix(proxy, "file:///") {
+		// Windows paths such as C:/x need an extra slash
+		proxy = "file:///" + strings.TrimPrefix(proxy, "file://")
+	}
+	return []string{"GOPROXY=" + proxy, "GOSUMDB=off"}, nil
+}
+
+// MissingModules returns the dependencies, given as go get arguments, that
+// are not available offline. Dependencies without an exact version are
+// skipped, as they cannot be checked without the network.
+func MissingModules(ctx context.Context, r runner.Runner, deps []string, proxyDir string) ([]string, error) {
+	root := proxyDir
+	if root == "" {
+		modCache, err := goEnv(ctx, r, "GOMODCACHE")
+		if err != nil {
+			return nil, err
+		}
+		root = filepath.Join(modCache, "cache", "download")
+	}
+
+	var missing []string
+	for _, dep := range deps {
+		importPath, version, ok := strings.Cut(dep, "@")
+		if !ok || !strings.HasPrefix(version, "v") {
+			continue
+		}
+		if !moduleAvailable(root, importPath, version) {
+			missing = append(missing, dep)
+		}
+	}
+	return missing, nil
+}
+
+// missingModuleLine matches the go command errors for modules that could not
+// be downloaded offline
+var missingModuleLine = regexp.MustCompile(`(\S+): (module lookup disabled|reading file://)`)
+
+// ParseMissingModules extracts the modules reported as unavailable in the
+// output of a go command run offline
+func ParseMissingModules(output string) []string {
+	seen := map[string]bool{}
+	var modules []string
+	for _, match := range missingModuleLine.FindAllStringSubmatch(output, -1) {
+		module := match[1]
+		if !seen[module] {
+			seen[module] = true
+			modules = append(modules, module)
+		}
+	}
+	sort.Strings(modules)
+	return modules
+}
+
+// moduleAvailable reports whether the zip of the module providing importPath,
+// at version, is in the download cache or proxy directory rooted at root.
+// importPath may be a package inside the module, so every prefix is tried.
+func moduleAvailable(root, importPath, version string) bool {
+	for prefix := importPath; prefix != "."; prefix = path.Dir(prefix) {
+		zip := filepath.Join(root, filepath.FromSlash(escapeModulePath(prefix)), "@v", version+".zip")
+		if _, err := os.Stat(zip); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeModulePath applies the module cache escaping, which replaces every
+// upper-case letter with an exclamation mark and the lower-case letter
+func escapeModulePath(modulePath string) string {
+	var sb strings.Builder
+	for _, r := range modulePath {
+		if unicode.IsUpper(r) {
+			sb.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// goEnv returns the value of a go environment variable
+func goEnv(ctx context.Context, r runner.Runner, name string) (string, error) {
+	result, err := r.Run(ctx, runner.Go("", "env", name))
+	if err != nil {
+		return "", fmt.Errorf("error reading go env %s: %v, Output: %s", name, err, result.Output)
+	}
+	return strings.TrimSpace(string(result.Output)), nil
+}
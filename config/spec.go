@@ -0,0 +1,209 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pol-cova/GoGinit/internal/goversion"
+	"gopkg.in/yaml.v3"
+)
+
+// SpecFileName is the name of the spec file recorded in every generated project
+const SpecFileName = "goginit.yaml"
+
+// Supported values for ProjectSpec.Database
+const (
+	DatabaseNone   = ""
+	DatabaseSQLite = "sqlite"
+)
+
+// ProjectSpec describes the scaffolding choices for a project. It can be read
+// from a goginit.yaml or JSON file and is written back into every generated
+// project so the choices are recorded and reproducible.
+//
+// Name is the project directory, Module the Go module path and Binary the
+// name of the cmd/<binary> main package; the last two default to Name.
+// FrameworkVersion overrides the version pinned in the framework registry.
+// GoVersion and Toolchain set the go and toolchain directives of go.mod;
+// by default go mod init writes the version of the installed toolchain.
+// Template optionally names a user template directory replacing the
+// built-in project layout. Hooks run before and after the generation.
+type ProjectSpec struct {
+	Name             string `yaml:"name" json:"name"`
+	Module           string `yaml:"module,omitempty" json:"module,omitempty"`
+	Binary           string `yaml:"binary,omitempty" json:"binary,omitempty"`
+	Framework        string `yaml:"framework" json:"framework"`
+	FrameworkVersion string `yaml:"frameworkVersion,omitempty" json:"frameworkVersion,omitempty"`
+	GoVersion        string `yaml:"goVersion,omitempty" json:"goVersion,omitempty"`
+	Toolchain        string `yaml:"toolchain,omitempty" json:"toolchain,omitempty"`
+	Database         string `yaml:"database,omitempty" json:"database,omitempty"`
+	Template         string `yaml:"template,omitempty" json:"template,omitempty"`
+	Hooks            Hooks  `yaml:"hooks,omitempty" json:"hooks,omitempty"`
+}
+
+// ModulePath returns the Go module path of the project, defaulting to its name
+func (s ProjectSpec) ModulePath() string {
+	if s.Module != "" {
+		return s.Module
+	}
+	return s.Name
+}
+
+// BinaryName returns the name of the cmd/<binary> directory, defaulting to
+// the project name
+func (s ProjectSpec) BinaryName() string {
+	if s.Binary != "" {
+		return s.Binary
+	}
+	return s.Name
+}
+
+// FrameworkConfig returns the registered configuration of the spec's
+// framework, with the version overridden by FrameworkVersion when set
+func (s ProjectSpec) FrameworkConfig() (FrameworkConfig, error) {
+	framework, err := GetFrameworkConfig(s.Framework)
+	if err != nil {
+		return framework, err
+	}
+	if s.FrameworkVersion != "" {
+		if framework.ImportPath == "" {
+			return framework, fmt.Errorf("framework %s has no dependency to pin a version for", s.Framework)
+		}
+		if s.FrameworkVersion != framework.Version {
+			// The requirement of another version is not known
+			framework.MinGoVersion = ""
+		}
+		framework.Version = s.FrameworkVersion
+	}
+	return framework, nil
+}
+
+// SetupDB reports whether the spec asks for a database
+func (s ProjectSpec) SetupDB() bool {
+	return s.Database != DatabaseNone
+}
+
+// Validate checks that the spec only uses supported values. Empty fields are
+// not an error, as they may still be filled in by the wizard.
+func (s ProjectSpec) Validate() error {
+	if s.Name != "" {
+		if err := ValidateProjectName(s.Name); err != nil {
+			return err
+		}
+	}
+	if s.Module != "" {
+		if err := ValidateModulePath(s.Module); err != nil {
+			return err
+		}
+	}
+	if s.Binary != "" {
+		if err := ValidateBinaryName(s.Binary); err != nil {
+			return err
+		}
+	}
+	if strings.ContainsAny(s.FrameworkVersion, " \t@") {
+		return fmt.Errorf("invalid framework version: %q", s.FrameworkVersion)
+	}
+	switch s.Database {
+	case DatabaseNone, DatabaseSQLite:
+	default:
+		return fmt.Errorf("unsupported database: %s", s.Database)
+	}
+	if err := s.validateGoVersion(); err != nil {
+		return err
+	}
+	if err := s.Hooks.validate(); err != nil {
+		return err
+	}
+	if s.Framework != "" {
+		framework, err := s.FrameworkConfig()
+		if err != nil {
+			return err
+		}
+		if s.GoVersion != "" && framework.MinGoVersion != "" && !goversion.AtLeast(s.GoVersion, framework.MinGoVersion) {
+			return fmt.Errorf("framework %s %s requires Go %s or later, the project targets Go %s", framework.Name, framework.Version, framework.MinGoVersion, s.GoVersion)
+		}
+	}
+	if s.GoVersion != "" && s.SetupDB() && !goversion.AtLeast(s.GoVersion, MinGoVersionSQLite) {
+		return fmt.Errorf("the %s database requires Go %s or later, the project targets Go %s", s.Database, MinGoVersionSQLite, s.GoVersion)
+	}
+	return nil
+}
+
+// validateGoVersion checks the go and toolchain directive values
+func (s ProjectSpec) validateGoVersion() error {
+	if s.GoVersion != "" && !goversion.IsValid(s.GoVersion) {
+		return fmt.Errorf("invalid Go version: %q, expected a release such as 1.22 or 1.22.3", s.GoVersion)
+	}
+	if s.Toolchain == "" {
+		return nil
+	}
+
+	version := strings.TrimPrefix(s.Toolchain, "go")
+	if !strings.HasPrefix(s.Toolchain, "go") || !goversion.IsValid(version) {
+		return fmt.Errorf("invalid toolchain: %q, expected a release such as go1.22.3", s.Toolchain)
+	}
+	if s.GoVersion == "" {
+		return errors.New("a toolchain can only be set together with a Go version")
+	}
+	// Older go commands do not know the toolchain directive
+	if !goversion.AtLeast(s.GoVersion, "1.21") {
+		return fmt.Errorf("a toolchain requires a Go version of 1.21 or later, got %s", s.GoVersion)
+	}
+	if goversion.Compare(version, s.GoVersion) < 0 {
+		return fmt.Errorf("toolchain %s is older than Go version %s", s.Toolchain, s.GoVersion)
+	}
+	return nil
+}
+
+// LoadSpec reads a project spec from a YAML or JSON file. Hook script paths
+// are kept as written, see scaffold.Options.SpecDir.
+func LoadSpec(path string) (ProjectSpec, error) {
+	var spec ProjectSpec
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return spec, fmt.Errorf("error reading spec file: %w", err)
+	}
+
+	// JSON is a subset of YAML, so one decoder handles both formats
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&spec); err != nil {
+		return spec, fmt.Errorf("error parsing spec file %s: %w", path, err)
+	}
+
+	if err := spec.Validate(); err != nil {
+		return spec, fmt.Errorf("invalid spec file %s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// EncodeSpec returns the goginit.yaml content recorded for the spec
+func EncodeSpec(spec ProjectSpec) ([]byte, error) {
+	data, err := yaml.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding spec: %w", err)
+	}
+
+	header := "# Scaffolding choices recorded by GoGinit.\n# Re-create this project with: goginit init --from path/to/" + SpecFileName + "\n"
+	return append([]byte(header), data...), nil
+}
+
+// WriteSpec records the spec as goginit.yaml in the project directory
+func WriteSpec(projectDir string, spec ProjectSpec) error {
+	data, err := EncodeSpec(spec)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(projectDir, SpecFileName)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing spec file: %w", err)
+	}
+	return nil
+}
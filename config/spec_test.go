@@ -0,0 +1,104 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFile writes a test file in a temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadSpecFormats(t *testing.T) {
+	yamlSpec := `name: example
+module: github.com/ourorg/example
+framework: gin
+goVersion: "1.25"
+database: sqlite
+hooks:
+  pre:
+    - echo pre
+  post:
+    - script: hooks/post.sh
+`
+	jsonSpec := `{
+  "name": "example",
+  "module": "github.com/ourorg/example",
+  "framework": "gin",
+  "goVersion": "1.25",
+  "database": "sqlite",
+  "hooks": {"pre": ["echo pre"], "post": [{"script": "hooks/post.sh"}]}
+}
+`
+	fromYAML, err := LoadSpec(writeFile(t, "spec.yaml", yamlSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := LoadSpec(writeFile(t, "spec.json", jsonSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := ProjectSpec{
+		Name:      "example",
+		Module:    "github.com/ourorg/example",
+		Framework: "gin",
+		GoVersion: "1.25",
+		Database:  DatabaseSQLite,
+		Hooks:     Hooks{Pre: []Hook{{Run: "echo pre"}}, Post: []Hook{{Script: "hooks/post.sh"}}},
+	}
+	if !reflect.DeepEqual(fromYAML, want) {
+		t.Errorf("YAML spec = %+v, want %+v", fromYAML, want)
+	}
+	if !reflect.DeepEqual(fromJSON, want) {
+		t.Errorf("JSON spec = %+v, want %+v", fromJSON, want)
+	}
+}
+
+func TestLoadSpecUnknownField(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "spec.yaml", content: "name: example\nframework: gin\nframwork: echo\n"},
+		{name: "spec.json", content: `{"name": "example", "framework": "gin", "goversion": "1.22"}`},
+	}
+	for _, tt := range tests {
+		_, err := LoadSpec(writeFile(t, tt.name, tt.content))
+		checkError(t, tt.name, err, "not found in type config.ProjectSpec")
+	}
+}
+
+func TestWriteSpec(t *testing.T) {
+	spec := ProjectSpec{
+		Name:             "example",
+		Module:           "github.com/ourorg/example",
+		Binary:           "server",
+		Framework:        "chi",
+		FrameworkVersion: "v5.0.12",
+		GoVersion:        "1.22",
+		Toolchain:        "go1.22.3",
+		Template:         "./templates/service",
+		Hooks:            Hooks{Pre: []Hook{{Run: "echo {{.ProjectName}}"}}, Post: []Hook{{Script: "hooks/post.sh"}}},
+	}
+	dir := t.TempDir()
+	if err := WriteSpec(dir, spec); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadSpec(filepath.Join(dir, SpecFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, spec) {
+		t.Errorf("loaded %+v, want %+v", got, spec)
+	}
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ModulePrefixEnv overrides the modulePrefix of the user configuration
+const ModulePrefixEnv = "GOGINIT_MODULE_PREFIX"
+
+// UserConfig holds per-user defaults, read from goginit/config.yaml in the
+// user configuration directory (for example ~/.config/goginit/config.yaml)
+type UserConfig struct {
+	// ModulePrefix is prepended to the project name to build the default
+	// module path, for example "github.com/ourorg"
+	ModulePrefix string `yaml:"modulePrefix"`
+
+	// Hooks run for every project, before the hooks of the project spec.
+	// Relative script paths are relative to the user config directory.
+	Hooks Hooks `yaml:"hooks,omitempty"`
+}
+
+// UserConfigDir returns the directory holding the GoGinit user configuration
+func UserConfigDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("error locating user config directory: %w", err)
+	}
+	return filepath.Join(dir, "goginit"), nil
+}
+
+// LoadUserConfig reads the user configuration. A missing file is not an
+// error and yields the zero configuration.
+func LoadUserConfig() (UserConfig, error) {
+	var cfg UserConfig
+
+	dir, err := UserConfigDir()
+	if err == nil {
+		path := filepath.Join(dir, "config.yaml")
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return cfg, fmt.Errorf("error reading user config: %w", err)
+		}
+		if len(data) > 0 {
+			dec := yaml.NewDecoder(bytes.NewReader(data))
+			dec.KnownFields(true)
+			if err := dec.Decode(&cfg); err != nil {
+				return cfg, fmt.Errorf("error parsing user config %s: %w", path, err)
+			}
+			if err := cfg.Hooks.validate(); err != nil {
+				return cfg, fmt.Errorf("invalid user config %s: %w", path, err)
+			}
+			cfg.Hooks.resolveScripts(dir)
+		}
+	}
+
+	if prefix := os.Getenv(ModulePrefixEnv); prefix != "" {
+		cfg.ModulePrefix = prefix
+	}
+	return cfg, nil
+}
+
+// DefaultModulePath builds the module path of a project from the configured
+// prefix, falling back to the bare project name
+func DefaultModulePath(prefix, projectName string) string {
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix == "" {
+		return projectName
+	}
+	return prefix + "/" + projectName
+}
+
+// ResolveTemplateDir returns the directory of a user template. name is either
+// a path to a template directory or the name of a directory in the templates
+// folder of the user configuration directory.
+func ResolveTemplateDir(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("template name cannot be empty")
+	}
+
+	dir := name
+	isPath := filepath.IsAbs(name) || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".")
+	if !isPath {
+		configDir, err := UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(configDir, "templates", name)
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("template %s not found: %w", name, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("template %s is not a directory", dir)
+	}
+	return dir, nil
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setUserConfig points the user configuration directory to a temporary
+// directory holding config, when it is not empty
+func setUserConfig(t *testing.T, config string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("AppData", filepath.Join(home, "AppData"))
+	if config == "" {
+		return
+	}
+
+	dir, err := UserConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDefaultModulePath(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string // config.yaml content, none when empty
+		env    string // GOGINIT_MODULE_PREFIX
+		want   string
+	}{
+		{name: "no prefix", want: "myservice"},
+		{name: "config", config: "modulePrefix: github.com/ourorg\n", want: "github.com/ourorg/myservice"},
+		{name: "env", env: "gitlab.com/team", want: "gitlab.com/team/myservice"},
+		{name: "env over config", config: "modulePrefix: github.com/ourorg\n", env: "gitlab.com/team", want: "gitlab.com/team/myservice"},
+		{name: "empty config prefix", config: "modulePrefix: \"\"\n", want: "myservice"},
+		{name: "empty env", config: "modulePrefix: github.com/ourorg\n", want: "github.com/ourorg/myservice"},
+		{name: "config trailing slash", config: "modulePrefix: github.com/ourorg/\n", want: "github.com/ourorg/myservice"},
+		{name: "env trailing slashes", env: "gitlab.com/team//", want: "gitlab.com/team/myservice"},
+		{name: "slash only", env: "/", want: "myservice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setUserConfig(t, tt.config)
+			t.Setenv(ModulePrefixEnv, tt.env)
+
+			cfg, err := LoadUserConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := DefaultModulePath(cfg.ModulePrefix, "myservice"); got != tt.want {
+				t.Errorf("DefaultModulePath(%q) = %s, want %s", cfg.ModulePrefix, got, tt.want)
+			}
+		})
+	}
+}
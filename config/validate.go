@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// windowsReserved lists file names that cannot be used on Windows, with or
+// without an extension
+var windowsReserved = []string{
+	"CON", "PRN", "AUX", "NUL",
+	"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+	"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9",
+}
+
+// ValidateProjectName checks that name can be used as the project directory
+// and as an element of a Go module path
+func ValidateProjectName(name string) error {
+	if err := checkPathElement(name); err != nil {
+		return fmt.Errorf("invalid project name %q: %w", name, err)
+	}
+	if strings.HasPrefix(name, "-") {
+		return fmt.Errorf("invalid project name %q: cannot start with a dash", name)
+	}
+	return nil
+}
+
+// ValidateModulePath checks that path is a valid Go module path
+func ValidateModulePath(path string) error {
+	if path == "" {
+		return fmt.Errorf("invalid module path: cannot be empty")
+	}
+	for _, elem := range strings.Split(path, "/") {
+		if elem == "" {
+			return fmt.Errorf("invalid module path %q: empty path element", path)
+		}
+		if err := checkPathElement(elem); err != nil {
+			return fmt.Errorf("invalid module path %q: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// ValidateBinaryName checks that name can be used for the cmd/<binary>
+// directory and the executable built from it
+func ValidateBinaryName(name string) error {
+	if err := checkPathElement(name); err != nil {
+		return fmt.Errorf("invalid binary name %q: %w", name, err)
+	}
+	if strings.HasPrefix(name, "-") {
+		return fmt.Errorf("invalid binary name %q: cannot start with a dash", name)
+	}
+	return nil
+}
+
+// CheckProjectDir fails if the project directory already exists and is not
+// empty
+func CheckProjectDir(dir string) error {
+	f, err := os.Open(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error checking project directory: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Readdirnames(1); err != io.EOF {
+		return fmt.Errorf("directory %s already exists and is not empty", dir)
+	}
+	return nil
+}
+
+// checkPathElement applies the rules of the go command for module path
+// elements: ASCII letters, digits, '-', '.', '_' and '~' only, no leading or
+// trailing dot, and no Windows reserved names
+func checkPathElement(elem string) error {
+	if elem == "" {
+		return fmt.Errorf("cannot be empty")
+	}
+	for _, r := range elem {
+		ok := 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' ||
+			r == '-' || r == '.' || r == '_' || r == '~'
+		if !ok {
+			return fmt.Errorf("invalid character %q, use letters, digits, '-', '.', '_' or '~'", r)
+		}
+	}
+	if strings.HasPrefix(elem, ".") || strings.HasSuffix(elem, ".") {
+		return fmt.Errorf("cannot start or end with a dot")
+	}
+
+	short, _, _ := strings.Cut(elem, ".")
+	for _, reserved := range windowsReserved {
+		if strings.EqualFold(short, reserved) {
+			return fmt.Errorf("%s is a reserved file name on Windows", short)
+		}
+	}
+	return nil
+}
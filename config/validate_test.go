@@ -0,0 +1,114 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateProjectName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string // Part of the error, empty when valid
+	}{
+		{name: "myservice"},
+		{name: "my-service_v2.1"},
+		{name: "a~b"},
+		{name: "", want: "cannot be empty"},
+		{name: "my service", want: "invalid character ' '"},
+		{name: "my/service", want: "invalid character '/'"},
+		{name: "café", want: "invalid character 'é'"},
+		{name: ".hidden", want: "cannot start or end with a dot"},
+		{name: "service.", want: "cannot start or end with a dot"},
+		{name: "-service", want: "cannot start with a dash"},
+		{name: "con", want: "reserved file name on Windows"},
+		{name: "LPT1.txt", want: "reserved file name on Windows"},
+	}
+	for _, tt := range tests {
+		checkError(t, tt.name, ValidateProjectName(tt.name), tt.want)
+	}
+}
+
+func TestValidateModulePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "myservice"},
+		{path: "github.com/ourorg/myservice"},
+		{path: "example.com/our-org/my_service/v2"},
+		{path: "", want: "cannot be empty"},
+		{path: "github.com//myservice", want: "empty path element"},
+		{path: "github.com/ourorg/", want: "empty path element"},
+		{path: "/myservice", want: "empty path element"},
+		{path: "github.com/our org/myservice", want: "invalid character ' '"},
+		{path: "github.com/ourorg/.myservice", want: "cannot start or end with a dot"},
+		{path: "example.com/aux/myservice", want: "reserved file name on Windows"},
+	}
+	for _, tt := range tests {
+		checkError(t, tt.path, ValidateModulePath(tt.path), tt.want)
+	}
+}
+
+func TestValidateBinaryName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "api"},
+		{name: "my-server"},
+		{name: "", want: "cannot be empty"},
+		{name: "bin/api", want: "invalid character '/'"},
+		{name: "-api", want: "cannot start with a dash"},
+		{name: "api.", want: "cannot start or end with a dot"},
+		{name: "nul", want: "reserved file name on Windows"},
+	}
+	for _, tt := range tests {
+		checkError(t, tt.name, ValidateBinaryName(tt.name), tt.want)
+	}
+}
+
+func TestCheckProjectDir(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty")
+	full := filepath.Join(dir, "full")
+	file := filepath.Join(dir, "file")
+	for _, d := range []string{empty, full} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(full, "go.mod"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{dir: filepath.Join(dir, "missing")},
+		{dir: empty},
+		{dir: full, want: "already exists and is not empty"},
+		{dir: file, want: "already exists"},
+	}
+	for _, tt := range tests {
+		checkError(t, tt.dir, CheckProjectDir(tt.dir), tt.want)
+	}
+}
+
+// checkError checks that err holds want, or is nil when want is empty
+func checkError(t *testing.T, input string, err error, want string) {
+	t.Helper()
+	switch {
+	case want == "" && err != nil:
+		t.Errorf("%q: unexpected error %v", input, err)
+	case want != "" && err == nil:
+		t.Errorf("%q: got no error, want %q", input, want)
+	case want != "" && !strings.Contains(err.Error(), want):
+		t.Errorf("%q: got %v, want %q", input, err, want)
+	}
+}
@@ -0,0 +1,248 @@
+// Package apidoc describes the HTTP API of a Go module from its source,
+// without building it: the handlers its routes are registered with, the
+// request and response types they read and write, and the JSON schemas of
+// those types.
+//
+// Types are followed through the declarations of the module only. Values of
+// types declared elsewhere, such as the result of a database driver call,
+// have no known schema.
+package apidoc
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pol-cova/GoGinit/internal/openapi"
+)
+
+// Index holds the declarations of the Go files of a module. Packages are
+// identified by name, and types are written as expressions qualified by
+// the name of their package, such as *models.User.
+type Index struct {
+	fset   *token.FileSet
+	files  map[string]*file
+	types  map[string]*typeDecl // by package.Name
+	funcs  map[string]*funcDecl // by package.Name, and package.Type.Method for methods
+	consts map[string][]any     // constant values of the named types, by package.Name
+
+	schemas    openapi.Ordered[*openapi.Schema]
+	components map[string]string // component name by package.Name
+}
+
+type file struct {
+	path    string
+	ast     *ast.File
+	pkg     string
+	imports map[string]string // package name by import name
+}
+
+type typeDecl struct {
+	spec *ast.TypeSpec
+	doc  *ast.CommentGroup
+	file *file
+}
+
+type funcDecl struct {
+	decl *ast.FuncDecl
+	file *file
+}
+
+// Load parses the Go files and indexes their declarations
+func Load(paths []string) (*Index, error) {
+	ix := &Index{
+		fset:       token.NewFileSet(),
+		files:      map[string]*file{},
+		types:      map[string]*typeDecl{},
+		funcs:      map[string]*funcDecl{},
+		consts:     map[string][]any{},
+		components: map[string]string{},
+	}
+	for _, path := range paths {
+		f, err := parser.ParseFile(ix.fset, path, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		ix.add(&file{path: path, ast: f, pkg: f.Name.Name, imports: importNames(f)})
+	}
+	return ix, nil
+}
+
+// importNames maps the names files refer to imported packages by to the
+// package names, taken as the last element of the import path without a
+// major version suffix
+func importNames(f *ast.File) map[string]string {
+	names := map[string]string{}
+	for _, imp := range f.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		elems := strings.Split(path, "/")
+		pkg := elems[len(elems)-1]
+		if len(elems) > 1 && len(pkg) > 1 && pkg[0] == 'v' && strings.Trim(pkg[1:], "0123456789") == "" {
+			pkg = elems[len(elems)-2]
+		}
+		pkg = strings.TrimPrefix(strings.TrimPrefix(pkg, "go-"), "go.")
+		name := pkg
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		names[name] = pkg
+	}
+	return names
+}
+
+func (ix *Index) add(f *file) {
+	ix.files[f.path] = f
+	for _, decl := range f.ast.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			key := f.pkg + "." + decl.Name.Name
+			if decl.Recv != nil && len(decl.Recv.List) == 1 {
+				key = f.pkg + "." + receiverName(decl.Recv.List[0].Type) + "." + decl.Name.Name
+			}
+			ix.funcs[key] = &funcDecl{decl: decl, file: f}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					doc := spec.Doc
+					if doc == nil && len(decl.Specs) == 1 {
+						doc = decl.Doc
+					}
+					ix.types[f.pkg+"."+spec.Name.Name] = &typeDecl{spec: spec, doc: doc, file: f}
+				case *ast.ValueSpec:
+					ix.addConsts(f, decl.Tok, spec)
+				}
+			}
+		}
+	}
+}
+
+// addConsts records the values of constants declared with a named type,
+// the enumerations of Go
+func (ix *Index) addConsts(f *file, tok token.Token, spec *ast.ValueSpec) {
+	typ, ok := spec.Type.(*ast.Ident)
+	if tok != token.CONST || !ok {
+		return
+	}
+	for _, value := range spec.Values {
+		lit, ok := value.(*ast.BasicLit)
+		if !ok {
+			continue
+		}
+		key := f.pkg + "." + typ.Name
+		switch lit.Kind {
+		case token.STRING:
+			if s, err := strconv.Unquote(lit.Value); err == nil {
+				ix.consts[key] = append(ix.consts[key], s)
+			}
+		case token.INT:
+			if n, err := strconv.ParseInt(lit.Value, 0, 64); err == nil {
+				ix.consts[key] = append(ix.consts[key], n)
+			}
+		}
+	}
+}
+
+// receiverName returns the name of the type of a method receiver
+func receiverName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(expr.X)
+	case *ast.IndexExpr:
+		return receiverName(expr.X)
+	case *ast.IndexListExpr:
+		return receiverName(expr.X)
+	case *ast.Ident:
+		return expr.Name
+	}
+	return ""
+}
+
+// Func is a function or method declared in the module
+type Func struct {
+	Name string // Name of the function, Type.Method for methods
+	fn   *funcDecl
+}
+
+// Handler resolves the handler expression of a route registered at line
+// of a file, such as handlers.GetUser or userHandler.Get, to the function
+// or method it designates. Variables are followed through the function
+// registering the route, so userHandler := handlers.NewUserHandler(repo)
+// gives the Get method of the type returned by NewUserHandler.
+func (ix *Index) Handler(path string, line int, handler string) (*Func, bool) {
+	f, ok := ix.files[path]
+	if !ok {
+		return nil, false
+	}
+	expr, err := parser.ParseExpr(handler)
+	if err != nil {
+		return nil, false
+	}
+
+	sc := &scope{ix: ix, file: f, vars: map[string]ast.Expr{}}
+	for _, decl := range f.ast.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil && ix.line(fn.Pos()) <= line && line <= ix.line(fn.End()) {
+			sc.declare(fn)
+		}
+	}
+	// http.HandlerFunc(handlers.Home) converts the handler
+	if call, ok := expr.(*ast.CallExpr); ok && len(call.Args) == 1 {
+		expr = call.Args[0]
+	}
+	fn := sc.callee(expr)
+	if fn == nil {
+		return nil, false
+	}
+	name := fn.decl.Name.Name
+	if fn.decl.Recv != nil && len(fn.decl.Recv.List) == 1 {
+		name = receiverName(fn.decl.Recv.List[0].Type) + "." + name
+	}
+	return &Func{Name: name, fn: fn}, true
+}
+
+func (ix *Index) line(pos token.Pos) int {
+	return ix.fset.Position(pos).Line
+}
+
+// Models declares a schema component for every exported type of the Go
+// files of a directory, in source order
+func (ix *Index) Models(dir string) {
+	var paths []string
+	for path := range ix.files {
+		if filepath.Dir(path) == filepath.Clean(dir) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		f := ix.files[path]
+		for _, decl := range f.ast.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				if spec := spec.(*ast.TypeSpec); spec.Name.IsExported() && spec.TypeParams == nil {
+					ix.component(f.pkg + "." + spec.Name.Name)
+				}
+			}
+		}
+	}
+}
+
+// Schemas returns the schema components declared for the types met so far,
+// sorted by name
+func (ix *Index) Schemas() openapi.Ordered[*openapi.Schema] {
+	schemas := append(openapi.Ordered[*openapi.Schema]{}, ix.schemas...)
+	sort.SliceStable(schemas, func(i, j int) bool {
+		return schemas[i].Name < schemas[j].Name
+	})
+	return schemas
+}
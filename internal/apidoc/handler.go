@@ -0,0 +1,409 @@
+package apidoc
+
+import (
+	"go/ast"
+	"go/token"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pol-cova/GoGinit/internal/openapi"
+)
+
+// DefaultStatus is the Status of the responses written with the default
+// status of the framework, such as the values returned by fuego and gofr
+// handlers
+const DefaultStatus = 0
+
+// Handler describes what a handler reads from requests and writes in
+// responses, as found in its body and in the functions it calls
+type Handler struct {
+	Name      string          // Name of the function, without its receiver type
+	Doc       string          // Text of the doc comment
+	Methods   []string        // HTTP methods the handler checks r.Method against
+	Query     []string        // Names of the query parameters read
+	Body      *openapi.Schema // Schema of the JSON request body, nil when none is read
+	Responses []Response      // Responses written, in source order
+}
+
+// Response is a response written by a handler
+type Response struct {
+	Status int             // HTTP status code, or DefaultStatus
+	Schema *openapi.Schema // Schema of the body, nil for none or unknown
+	Type   string          // Content type of the body, empty without one
+}
+
+// Response content types
+const (
+	JSON = "application/json"
+	Text = "text/plain"
+)
+
+// bindNames are the methods decoding a JSON request body into their
+// pointer argument, in the supported frameworks and encoding/json
+var bindNames = map[string]bool{
+	"Bind": true, "BindJSON": true, "ShouldBind": true, "ShouldBindJSON": true,
+	"BodyParser": true, "Decode": true,
+}
+
+// jsonNames are the methods writing a JSON response, with the status first
+var jsonNames = map[string]bool{
+	"JSON": true, "IndentedJSON": true, "PureJSON": true, "SecureJSON": true,
+	"AbortWithStatusJSON": true, "JSONPretty": true,
+}
+
+// statusNames are the methods writing a response without a body
+var statusNames = map[string]bool{
+	"NoContent": true, "SendStatus": true, "AbortWithStatus": true, "Status": true,
+	"WriteHeader": true,
+}
+
+// queryNames are the methods reading a query parameter named by their
+// first argument
+var queryNames = map[string]bool{
+	"Query": true, "DefaultQuery": true, "GetQuery": true, "QueryArray": true,
+	"QueryParam": true, "QueryInt": true, "QueryBool": true, "QueryFloat": true,
+}
+
+// fuegoErrors are the status codes of the errors of fuego
+var fuegoErrors = map[string]int{
+	"BadRequestError": http.StatusBadRequest, "UnauthorizedError": http.StatusUnauthorized,
+	"ForbiddenError": http.StatusForbidden, "NotFoundError": http.StatusNotFound,
+	"NotAcceptableError": http.StatusNotAcceptable, "ConflictError": http.StatusConflict,
+	"InternalServerError": http.StatusInternalServerError,
+}
+
+// gofrErrors are the status codes of the errors of gofr.dev/pkg/gofr/http
+var gofrErrors = map[string]int{
+	"http.ErrorInvalidParam": http.StatusBadRequest, "http.ErrorMissingParam": http.StatusBadRequest,
+	"http.ErrorEntityNotFound": http.StatusNotFound, "http.ErrorEntityAlreadyExist": http.StatusConflict,
+	"http.ErrorInvalidRoute": http.StatusNotFound, "http.ErrorRequestTimeout": http.StatusRequestTimeout,
+}
+
+// Describe returns what a handler of the framework reads and writes
+func (ix *Index) Describe(fn *Func, framework string) Handler {
+	h := Handler{Name: fn.fn.decl.Name.Name, Doc: docText(fn.fn.decl.Doc)}
+	d := &describer{ix: ix, framework: framework, handler: &h, visited: map[*funcDecl]bool{}}
+	d.signature(fn.fn)
+	d.walk(fn.fn, 0)
+	if d.result != nil && !d.statusSet {
+		d.respond(DefaultStatus, d.result, JSON)
+	}
+	return h
+}
+
+type describer struct {
+	ix        *Index
+	framework string
+	handler   *Handler
+	visited   map[*funcDecl]bool
+
+	result    *openapi.Schema // Schema of the value returned by fuego handlers
+	statusSet bool            // Whether the handler sets the status of the value it returns
+}
+
+// signature reads the request and response types of typed handlers, such
+// as func(c fuego.ContextWithBody[models.User]) (models.User, error)
+func (d *describer) signature(fn *funcDecl) {
+	sc := d.ix.newScope(fn)
+	for _, param := range fn.decl.Type.Params.List {
+		if index, ok := qualify(d.ix, fn.file, param.Type).(*ast.IndexExpr); ok && typeKey(index.X) == "fuego.ContextWithBody" {
+			d.handler.Body = d.ix.Schema(index.Index)
+		}
+	}
+	results := fn.decl.Type.Results
+	if d.framework == "fuego" && results != nil && len(results.List) == 2 {
+		d.result = orEmpty(d.ix.Schema(sc.qualify(results.List[0].Type)))
+	}
+}
+
+// walk inspects the body of a handler, and the functions of the module it
+// calls with its arguments, such as helpers writing error responses
+func (d *describer) walk(fn *funcDecl, depth int) {
+	if fn.decl.Body == nil || d.visited[fn] || depth > 2 {
+		return
+	}
+	d.visited[fn] = true
+	sc := d.ix.newScope(fn)
+	status, contentType := DefaultStatus, Text
+
+	ast.Inspect(fn.decl.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BinaryExpr:
+			// r.Method != http.MethodGet
+			if n.Op == token.EQL || n.Op == token.NEQ {
+				for _, side := range []ast.Expr{n.X, n.Y} {
+					if sel, ok := side.(*ast.SelectorExpr); ok && isIdent(sel.X, "http") && strings.HasPrefix(sel.Sel.Name, "Method") {
+						d.handler.Methods = append(d.handler.Methods, strings.ToUpper(strings.TrimPrefix(sel.Sel.Name, "Method")))
+					}
+				}
+			}
+		case *ast.ReturnStmt:
+			// gofr handlers return their response
+			if d.framework == "gofr" && depth == 0 {
+				d.gofrResult(sc, n)
+			}
+		case *ast.FuncLit:
+			return false
+		case *ast.CompositeLit:
+			// return m, fuego.NotFoundError{Detail: err.Error()}
+			if key := typeKey(sc.qualify(n.Type)); d.framework == "fuego" && strings.HasPrefix(key, "fuego.") {
+				d.fuegoError(strings.TrimPrefix(key, "fuego."), n)
+			} else if status, ok := gofrErrors[key]; ok && d.framework == "gofr" {
+				d.respond(status, nil, "")
+			}
+		case *ast.CallExpr:
+			var name string
+			var recv ast.Expr
+			switch fun := n.Fun.(type) {
+			case *ast.Ident:
+				name = fun.Name
+			case *ast.SelectorExpr:
+				name, recv = fun.Sel.Name, fun.X
+			}
+			args := n.Args
+
+			switch {
+			case name == "SetStatus" && len(args) == 1:
+				status = statusCode(args[0])
+				if d.result != nil && depth == 0 {
+					// fuego writes the returned value with this status
+					d.statusSet = true
+					schema := d.result
+					if status == http.StatusNoContent {
+						schema = nil
+					}
+					d.respond(status, schema, JSON)
+				}
+			case name == "JSON" && len(args) == 1 && isCall(recv, "Bind"):
+				// c.Bind().JSON(&body) in fiber
+				d.bind(sc, args[0])
+				return false
+			case bindNames[name] && len(args) >= 1:
+				d.bind(sc, args[len(args)-1])
+			case name == "JSON" && len(args) == 1:
+				// c.Status(fiber.StatusCreated).JSON(v) in fiber
+				code := http.StatusOK
+				if inner, ok := recv.(*ast.CallExpr); ok && isCall(inner, "Status") && len(inner.Args) == 1 {
+					code = statusCode(inner.Args[0])
+				}
+				d.respond(code, d.value(sc, args[0]), JSON)
+				return false
+			case jsonNames[name] && len(args) >= 2:
+				d.respond(statusCode(args[0]), d.value(sc, args[len(args)-1]), JSON)
+			case (name == "String" || name == "HTML") && len(args) >= 2:
+				d.respond(statusCode(args[0]), &openapi.Schema{Type: openapi.Types{"string"}}, Text)
+			case name == "SendString" && len(args) == 1:
+				d.respond(http.StatusOK, &openapi.Schema{Type: openapi.Types{"string"}}, Text)
+			case name == "Encode" && len(args) == 1:
+				if status != -1 {
+					code := status
+					if code == DefaultStatus {
+						code = http.StatusOK
+					}
+					d.respond(code, d.value(sc, args[0]), JSON)
+				}
+			case name == "Set" && len(args) == 2 && isCall(recv, "Header"):
+				// w.Header().Set("Content-Type", "application/json")
+				if key, ok := stringValue(args[0]); ok && http.CanonicalHeaderKey(key) == "Content-Type" {
+					if value, ok := stringValue(args[1]); ok {
+						contentType, _, _ = strings.Cut(value, ";")
+					}
+				}
+			case name == "Write" && len(args) == 1 && d.isResponseWriter(sc, recv),
+				strings.HasPrefix(name, "Fprint") && isIdent(recv, "fmt") && len(args) >= 1 && d.isResponseWriter(sc, args[0]):
+				d.write(status, contentType)
+			case name == "WriteHeader" && len(args) == 1:
+				status = statusCode(args[0])
+				d.respond(status, nil, "")
+			case statusNames[name] && len(args) == 1:
+				d.respond(statusCode(args[0]), nil, "")
+			case name == "Error" && isIdent(recv, "http") && len(args) == 3:
+				d.respond(statusCode(args[2]), &openapi.Schema{Type: openapi.Types{"string"}}, Text)
+			case queryNames[name] && len(args) >= 1:
+				d.query(args[0])
+			case name == "Get" && len(args) == 1 && isCall(recv, "Query"):
+				// r.URL.Query().Get("name")
+				d.query(args[0])
+			case name == "Param" && d.framework == "gofr" && len(args) == 1:
+				d.query(args[0])
+			case len(args) == 3 && d.isResponseWriter(sc, args[0]) && statusCode(args[1]) > 0:
+				// Helpers such as respond(w, http.StatusCreated, v)
+				d.respond(statusCode(args[1]), d.value(sc, args[2]), JSON)
+				return false
+			default:
+				if callee := sc.callee(n.Fun); callee != nil {
+					d.walk(callee, depth+1)
+				}
+			}
+		}
+		return true
+	})
+}
+
+// gofrResult records the response of a return statement of a gofr
+// handler: the value returned, or none for return nil, nil
+func (d *describer) gofrResult(sc *scope, ret *ast.ReturnStmt) {
+	switch {
+	case len(ret.Results) == 2 && isNil(ret.Results[0]) && isNil(ret.Results[1]):
+		d.respond(DefaultStatus, nil, "")
+	case len(ret.Results) == 2 && !isNil(ret.Results[0]):
+		d.respond(DefaultStatus, orEmpty(d.ix.Schema(sc.typeOf(ret.Results[0]))), JSON)
+	case len(ret.Results) == 1:
+		// return h.repo.List(ctx)
+		if results := sc.results(ret.Results[0]); len(results) == 2 {
+			d.respond(DefaultStatus, orEmpty(d.ix.Schema(results[0])), JSON)
+		}
+	}
+}
+
+// write records a response whose body is written as bytes, such as
+// w.Write(data) or fmt.Fprintf(w, ...), with the status and content type
+// set before
+func (d *describer) write(status int, contentType string) {
+	if status == DefaultStatus {
+		status = http.StatusOK
+	}
+	schema := &openapi.Schema{}
+	if contentType == Text {
+		schema.Type = openapi.Types{"string"}
+	}
+	d.respond(status, schema, contentType)
+}
+
+// fuegoError records the response written for an error of fuego, such as
+// fuego.NotFoundError or fuego.HTTPError{Status: http.StatusConflict}
+func (d *describer) fuegoError(name string, lit *ast.CompositeLit) {
+	if name != "HTTPError" {
+		if status, ok := fuegoErrors[name]; ok {
+			d.respond(status, nil, "")
+		}
+		return
+	}
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok && isIdent(kv.Key, "Status") {
+			d.respond(statusCode(kv.Value), nil, "")
+		}
+	}
+}
+
+// bind records the type of the request body decoded into arg
+func (d *describer) bind(sc *scope, arg ast.Expr) {
+	if d.handler.Body != nil {
+		return
+	}
+	if star, ok := sc.typeOf(arg).(*ast.StarExpr); ok {
+		d.handler.Body = d.ix.Schema(star.X)
+	}
+}
+
+// respond records a response. Responses with an unknown status are left
+// out.
+func (d *describer) respond(status int, schema *openapi.Schema, contentType string) {
+	if status < 0 {
+		return
+	}
+	if schema == nil {
+		contentType = ""
+	}
+	d.handler.Responses = append(d.handler.Responses, Response{Status: status, Schema: schema, Type: contentType})
+}
+
+// query records a query parameter read by name
+func (d *describer) query(arg ast.Expr) {
+	name, ok := stringValue(arg)
+	if !ok {
+		return
+	}
+	for _, q := range d.handler.Query {
+		if q == name {
+			return
+		}
+	}
+	d.handler.Query = append(d.handler.Query, name)
+}
+
+// value returns the schema of a response value. Map literals, such as
+// gin.H{"error": err.Error()}, give an object with their keys.
+func (d *describer) value(sc *scope, expr ast.Expr) *openapi.Schema {
+	if lit, ok := expr.(*ast.CompositeLit); ok && len(lit.Elts) > 0 {
+		typ := sc.qualify(lit.Type)
+		if _, isMap := typ.(*ast.MapType); isMap || typeKey(typ) != "" && d.ix.types[typeKey(typ)] == nil {
+			object := &openapi.Schema{Type: openapi.Types{"object"}}
+			for _, elt := range lit.Elts {
+				kv, ok := elt.(*ast.KeyValueExpr)
+				if !ok {
+					return object
+				}
+				key, ok := kv.Key.(*ast.BasicLit)
+				if !ok || key.Kind != token.STRING {
+					return object
+				}
+				name, _ := strconv.Unquote(key.Value)
+				object.Properties = append(object.Properties, openapi.Named[*openapi.Schema]{Name: name, Value: orEmpty(d.ix.Schema(sc.typeOf(kv.Value)))})
+			}
+			return object
+		}
+	}
+	return d.ix.Schema(sc.typeOf(expr))
+}
+
+// isResponseWriter reports whether expr is a variable of type
+// http.ResponseWriter
+func (d *describer) isResponseWriter(sc *scope, expr ast.Expr) bool {
+	return typeKey(sc.typeOf(expr)) == "http.ResponseWriter"
+}
+
+// statusCode returns the HTTP status of a literal or a constant such as
+// http.StatusCreated or fiber.StatusCreated, -1 when unknown
+func statusCode(expr ast.Expr) int {
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		if code, err := strconv.Atoi(expr.Value); err == nil && code >= 100 && code < 600 {
+			return code
+		}
+	case *ast.SelectorExpr:
+		name, ok := strings.CutPrefix(expr.Sel.Name, "Status")
+		if !ok {
+			return -1
+		}
+		for code := 100; code < 600; code++ {
+			if text := http.StatusText(code); text != "" && strings.EqualFold(strings.NewReplacer(" ", "", "-", "", "'", "").Replace(text), name) {
+				return code
+			}
+		}
+	}
+	return -1
+}
+
+// stringValue returns the value of a string literal
+func stringValue(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+// isCall reports whether expr is a call of a method named name
+func isCall(expr ast.Expr, name string) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == name
+}
+
+// isIdent reports whether expr is the identifier name
+func isIdent(expr ast.Expr, name string) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == name
+}
+
+// isNil reports whether expr is nil
+func isNil(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "nil"
+}
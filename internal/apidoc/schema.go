@@ -0,0 +1,209 @@
+package apidoc
+
+import (
+	"go/ast"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pol-cova/GoGinit/internal/openapi"
+)
+
+// Schema returns the JSON schema of a qualified type, declaring a component
+// for every named type of the module it uses. Types declared outside the
+// module have an empty schema, accepting any value, except the standard
+// types with a known JSON form such as time.Time.
+func (ix *Index) Schema(typ ast.Expr) *openapi.Schema {
+	switch typ := typ.(type) {
+	case nil:
+		return nil
+	case *ast.StarExpr:
+		return ix.Schema(typ.X)
+	case *ast.Ident:
+		return basicSchema(typ.Name)
+	case *ast.ArrayType:
+		if ident, ok := typ.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return &openapi.Schema{Type: openapi.Types{"string"}, Format: "byte"}
+		}
+		return &openapi.Schema{Type: openapi.Types{"array"}, Items: orEmpty(ix.Schema(typ.Elt))}
+	case *ast.MapType:
+		return &openapi.Schema{
+			Type:                 openapi.Types{"object"},
+			AdditionalProperties: &openapi.Additional{Allowed: true, Schema: ix.Schema(typ.Value)},
+		}
+	case *ast.SelectorExpr:
+		switch typeKey(typ) {
+		case "time.Time":
+			return &openapi.Schema{Type: openapi.Types{"string"}, Format: "date-time"}
+		case "time.Duration":
+			return &openapi.Schema{Type: openapi.Types{"integer"}, Format: "int64"}
+		case "uuid.UUID":
+			return &openapi.Schema{Type: openapi.Types{"string"}, Format: "uuid"}
+		case "json.RawMessage":
+			return &openapi.Schema{}
+		}
+		if name, ok := ix.component(typeKey(typ)); ok {
+			return &openapi.Schema{Ref: openapi.Ref("schemas", name)}
+		}
+	}
+	return &openapi.Schema{}
+}
+
+// basicSchema returns the schema of a predeclared type
+func basicSchema(name string) *openapi.Schema {
+	switch name {
+	case "string":
+		return &openapi.Schema{Type: openapi.Types{"string"}}
+	case "bool":
+		return &openapi.Schema{Type: openapi.Types{"boolean"}}
+	case "int", "int8", "int16", "uint", "uint8", "uint16", "byte", "uintptr":
+		return &openapi.Schema{Type: openapi.Types{"integer"}}
+	case "int32", "rune", "uint32":
+		return &openapi.Schema{Type: openapi.Types{"integer"}, Format: "int32"}
+	case "int64", "uint64":
+		return &openapi.Schema{Type: openapi.Types{"integer"}, Format: "int64"}
+	case "float32":
+		return &openapi.Schema{Type: openapi.Types{"number"}, Format: "float"}
+	case "float64":
+		return &openapi.Schema{Type: openapi.Types{"number"}, Format: "double"}
+	}
+	return &openapi.Schema{}
+}
+
+func orEmpty(s *openapi.Schema) *openapi.Schema {
+	if s == nil {
+		return &openapi.Schema{}
+	}
+	return s
+}
+
+// component declares the schema component of a named type of the module
+// and returns its name: the type name, prefixed with the package name when
+// two packages declare the same name
+func (ix *Index) component(key string) (string, bool) {
+	if name, ok := ix.components[key]; ok {
+		return name, true
+	}
+	td := ix.types[key]
+	if td == nil || td.spec.TypeParams != nil {
+		return "", false
+	}
+
+	name := td.spec.Name.Name
+	if _, taken := ix.schemas.Get(name); taken {
+		name = strings.ToUpper(td.file.pkg[:1]) + td.file.pkg[1:] + name
+	}
+	// Reserve the name first, the type may refer to itself
+	ix.components[key] = name
+	i := len(ix.schemas)
+	ix.schemas = append(ix.schemas, openapi.Named[*openapi.Schema]{Name: name})
+
+	var schema *openapi.Schema
+	if st, ok := td.spec.Type.(*ast.StructType); ok {
+		schema = ix.object(td.file, st, 0)
+	} else {
+		schema = orEmpty(ix.Schema(qualify(ix, td.file, td.spec.Type)))
+		if schema.Ref == "" {
+			schema.Enum = ix.consts[key]
+		}
+	}
+	if schema.Ref == "" {
+		schema.Description = docText(td.doc)
+	}
+	ix.schemas[i].Value = schema
+	return name, true
+}
+
+// object returns the schema of a struct, following its json tags: fields
+// without omitempty are required, and the fields of embedded structs
+// without a tag are promoted
+func (ix *Index) object(f *file, st *ast.StructType, depth int) *openapi.Schema {
+	schema := &openapi.Schema{Type: openapi.Types{"object"}}
+	for _, field := range st.Fields.List {
+		name, omitempty, skip := jsonTag(field)
+		if skip {
+			continue
+		}
+		typ := qualify(ix, f, field.Type)
+
+		if len(field.Names) == 0 && name == "" {
+			// Embedded struct, its fields are promoted
+			if td := ix.types[typeKey(deref(typ))]; td != nil && depth < 8 {
+				if embedded, ok := td.spec.Type.(*ast.StructType); ok {
+					inner := ix.object(td.file, embedded, depth+1)
+					for _, prop := range inner.Properties {
+						if _, ok := schema.Properties.Get(prop.Name); !ok {
+							schema.Properties = append(schema.Properties, prop)
+						}
+					}
+					schema.Required = append(schema.Required, inner.Required...)
+					continue
+				}
+			}
+			name = receiverName(deref(field.Type))
+		}
+
+		names := []string{name}
+		if name == "" {
+			names = names[:0]
+			for _, ident := range field.Names {
+				names = append(names, ident.Name)
+			}
+		}
+		for i, n := range names {
+			if name == "" && !field.Names[i].IsExported() {
+				continue
+			}
+			prop := orEmpty(ix.Schema(typ))
+			if _, pointer := typ.(*ast.StarExpr); pointer && prop.Ref == "" && len(prop.Type) > 0 {
+				prop.Nullable = true
+			}
+			if prop.Ref == "" {
+				prop.Description = docText(field.Doc)
+			}
+			schema.Properties = append(schema.Properties, openapi.Named[*openapi.Schema]{Name: n, Value: prop})
+			if !omitempty {
+				schema.Required = append(schema.Required, n)
+			}
+		}
+	}
+	return schema
+}
+
+// jsonTag returns the name and omitempty option of the json tag of a
+// field, and whether the field is left out of JSON
+func jsonTag(field *ast.Field) (name string, omitempty, skip bool) {
+	if len(field.Names) > 0 {
+		exported := false
+		for _, ident := range field.Names {
+			exported = exported || ident.IsExported()
+		}
+		if !exported {
+			return "", false, true
+		}
+	}
+	if field.Tag == nil {
+		return "", false, false
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return "", false, false
+	}
+	value, ok := reflect.StructTag(tag).Lookup("json")
+	if !ok {
+		return "", false, false
+	}
+	if value == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(value, ",")
+	for _, opt := range strings.Split(opts, ",") {
+		omitempty = omitempty || opt == "omitempty" || opt == "omitzero"
+	}
+	return name, omitempty, false
+}
+
+// docText returns the text of a doc comment, without trailing newlines
+func docText(doc *ast.CommentGroup) string {
+	return strings.TrimSpace(doc.Text())
+}
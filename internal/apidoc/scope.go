@@ -0,0 +1,312 @@
+package apidoc
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// scope resolves the types of the expressions of a function, from its
+// parameters and the variables it declares
+type scope struct {
+	ix   *Index
+	file *file
+	vars map[string]ast.Expr // qualified type by variable name
+}
+
+// newScope returns the scope of a function of the index
+func (ix *Index) newScope(fn *funcDecl) *scope {
+	sc := &scope{ix: ix, file: fn.file, vars: map[string]ast.Expr{}}
+	sc.declare(fn.decl)
+	return sc
+}
+
+// declare adds the receiver, parameters and variables of a function. The
+// variables of the function literals it holds are added too, shadowing
+// being ignored.
+func (sc *scope) declare(fn *ast.FuncDecl) {
+	if fn.Recv != nil {
+		sc.params(fn.Recv)
+	}
+	sc.params(fn.Type.Params)
+	if fn.Body == nil {
+		return
+	}
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			sc.params(n.Type.Params)
+		case *ast.AssignStmt:
+			if n.Tok != token.DEFINE {
+				return true
+			}
+			if len(n.Rhs) == 1 && len(n.Lhs) > 1 {
+				results := sc.results(n.Rhs[0])
+				for i, lhs := range n.Lhs {
+					if i < len(results) {
+						sc.set(lhs, results[i])
+					}
+				}
+				return true
+			}
+			for i, lhs := range n.Lhs {
+				if i < len(n.Rhs) {
+					sc.set(lhs, sc.typeOf(n.Rhs[i]))
+				}
+			}
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if n.Type != nil {
+					sc.set(name, sc.qualify(n.Type))
+				} else if i < len(n.Values) {
+					sc.set(name, sc.typeOf(n.Values[i]))
+				}
+			}
+		case *ast.RangeStmt:
+			if n.Tok == token.DEFINE && n.Value != nil {
+				switch t := deref(sc.typeOf(n.X)).(type) {
+				case *ast.ArrayType:
+					sc.set(n.Value, t.Elt)
+				case *ast.MapType:
+					sc.set(n.Value, t.Value)
+				}
+			}
+		}
+		return true
+	})
+}
+
+func (sc *scope) params(fields *ast.FieldList) {
+	if fields == nil {
+		return
+	}
+	for _, field := range fields.List {
+		for _, name := range field.Names {
+			sc.set(name, sc.qualify(field.Type))
+		}
+	}
+}
+
+func (sc *scope) set(lhs ast.Expr, typ ast.Expr) {
+	if ident, ok := lhs.(*ast.Ident); ok && ident.Name != "_" && typ != nil {
+		sc.vars[ident.Name] = typ
+	}
+}
+
+// isPackage reports whether an identifier names an imported package
+func (sc *scope) isPackage(expr ast.Expr) (string, bool) {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	if _, isVar := sc.vars[ident.Name]; isVar {
+		return "", false
+	}
+	pkg, ok := sc.file.imports[ident.Name]
+	return pkg, ok
+}
+
+// qualify returns a type expression of the file with the types of the
+// package of the file and the imported packages qualified by package name
+func (sc *scope) qualify(expr ast.Expr) ast.Expr {
+	return qualify(sc.ix, sc.file, expr)
+}
+
+func qualify(ix *Index, f *file, expr ast.Expr) ast.Expr {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if _, ok := ix.types[f.pkg+"."+expr.Name]; ok {
+			return &ast.SelectorExpr{X: ast.NewIdent(f.pkg), Sel: expr}
+		}
+		return expr
+	case *ast.SelectorExpr:
+		if ident, ok := expr.X.(*ast.Ident); ok {
+			if pkg, ok := f.imports[ident.Name]; ok {
+				return &ast.SelectorExpr{X: ast.NewIdent(pkg), Sel: expr.Sel}
+			}
+		}
+		return expr
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: qualify(ix, f, expr.X)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: expr.Len, Elt: qualify(ix, f, expr.Elt)}
+	case *ast.MapType:
+		return &ast.MapType{Key: qualify(ix, f, expr.Key), Value: qualify(ix, f, expr.Value)}
+	case *ast.IndexExpr:
+		return &ast.IndexExpr{X: qualify(ix, f, expr.X), Index: qualify(ix, f, expr.Index)}
+	case *ast.Ellipsis:
+		return &ast.ArrayType{Elt: qualify(ix, f, expr.Elt)}
+	case *ast.ParenExpr:
+		return qualify(ix, f, expr.X)
+	}
+	return expr
+}
+
+// typeOf returns the qualified type of an expression, nil when unknown
+func (sc *scope) typeOf(expr ast.Expr) ast.Expr {
+	switch expr := expr.(type) {
+	case *ast.ParenExpr:
+		return sc.typeOf(expr.X)
+	case *ast.Ident:
+		switch expr.Name {
+		case "true", "false":
+			return ast.NewIdent("bool")
+		}
+		return sc.vars[expr.Name]
+	case *ast.BasicLit:
+		switch expr.Kind {
+		case token.STRING:
+			return ast.NewIdent("string")
+		case token.INT:
+			return ast.NewIdent("int")
+		case token.FLOAT:
+			return ast.NewIdent("float64")
+		}
+	case *ast.CompositeLit:
+		if expr.Type != nil {
+			return sc.qualify(expr.Type)
+		}
+	case *ast.UnaryExpr:
+		if expr.Op == token.AND {
+			if t := sc.typeOf(expr.X); t != nil {
+				return &ast.StarExpr{X: t}
+			}
+		}
+	case *ast.StarExpr:
+		if t, ok := sc.typeOf(expr.X).(*ast.StarExpr); ok {
+			return t.X
+		}
+	case *ast.IndexExpr:
+		switch t := deref(sc.typeOf(expr.X)).(type) {
+		case *ast.ArrayType:
+			return t.Elt
+		case *ast.MapType:
+			return t.Value
+		}
+	case *ast.SelectorExpr:
+		if _, ok := sc.isPackage(expr.X); ok {
+			return nil
+		}
+		return sc.ix.field(sc.typeOf(expr.X), expr.Sel.Name)
+	case *ast.CallExpr:
+		if results := sc.results(expr); len(results) > 0 {
+			return results[0]
+		}
+	}
+	return nil
+}
+
+// results returns the qualified result types of a call
+func (sc *scope) results(expr ast.Expr) []ast.Expr {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	if ident, ok := call.Fun.(*ast.Ident); ok && len(call.Args) > 0 {
+		switch ident.Name {
+		case "make":
+			return []ast.Expr{sc.qualify(call.Args[0])}
+		case "new":
+			return []ast.Expr{&ast.StarExpr{X: sc.qualify(call.Args[0])}}
+		}
+	}
+	// err.Error() and the String methods of fmt.Stringer
+	if sel, ok := call.Fun.(*ast.SelectorExpr); ok && len(call.Args) == 0 && (sel.Sel.Name == "Error" || sel.Sel.Name == "String") {
+		return []ast.Expr{ast.NewIdent("string")}
+	}
+	// Conversions such as models.Status(s)
+	switch fun := call.Fun.(type) {
+	case *ast.ArrayType, *ast.MapType:
+		return []ast.Expr{sc.qualify(fun)}
+	case *ast.Ident, *ast.SelectorExpr:
+		if key := typeKey(sc.qualify(fun)); key != "" && sc.ix.types[key] != nil {
+			return []ast.Expr{sc.qualify(fun)}
+		}
+	}
+
+	fn := sc.callee(call.Fun)
+	if fn == nil || fn.decl.Type.Results == nil {
+		return nil
+	}
+	var results []ast.Expr
+	for _, field := range fn.decl.Type.Results.List {
+		typ := qualify(sc.ix, fn.file, field.Type)
+		for i := 0; i < max(1, len(field.Names)); i++ {
+			results = append(results, typ)
+		}
+	}
+	return results
+}
+
+// callee returns the function or method designated by expr
+func (sc *scope) callee(expr ast.Expr) *funcDecl {
+	switch expr := expr.(type) {
+	case *ast.ParenExpr:
+		return sc.callee(expr.X)
+	case *ast.IndexExpr:
+		return sc.callee(expr.X)
+	case *ast.Ident:
+		return sc.ix.funcs[sc.file.pkg+"."+expr.Name]
+	case *ast.SelectorExpr:
+		if pkg, ok := sc.isPackage(expr.X); ok {
+			return sc.ix.funcs[pkg+"."+expr.Sel.Name]
+		}
+		if key := typeKey(deref(sc.typeOf(expr.X))); key != "" {
+			return sc.ix.funcs[key+"."+expr.Sel.Name]
+		}
+	}
+	return nil
+}
+
+// field returns the qualified type of a field of a struct type, promoted
+// fields included
+func (ix *Index) field(typ ast.Expr, name string) ast.Expr {
+	return ix.fieldDepth(typ, name, 0)
+}
+
+func (ix *Index) fieldDepth(typ ast.Expr, name string, depth int) ast.Expr {
+	td := ix.types[typeKey(deref(typ))]
+	if td == nil || depth > 8 {
+		return nil
+	}
+	st, ok := td.spec.Type.(*ast.StructType)
+	if !ok {
+		return nil
+	}
+	for _, f := range st.Fields.List {
+		for _, ident := range f.Names {
+			if ident.Name == name {
+				return qualify(ix, td.file, f.Type)
+			}
+		}
+	}
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			embedded := qualify(ix, td.file, f.Type)
+			if receiverName(deref(f.Type)) == name {
+				return embedded
+			}
+			if t := ix.fieldDepth(embedded, name, depth+1); t != nil {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+// typeKey returns package.Name for a qualified named type
+func typeKey(expr ast.Expr) string {
+	if sel, ok := expr.(*ast.SelectorExpr); ok {
+		if pkg, ok := sel.X.(*ast.Ident); ok {
+			return pkg.Name + "." + sel.Sel.Name
+		}
+	}
+	return ""
+}
+
+// deref returns the type a pointer type points to
+func deref(expr ast.Expr) ast.Expr {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		return star.X
+	}
+	return expr
+}
@@ -0,0 +1,448 @@
+// Package astedit adds route registrations and imports to existing Go files.
+// Edits are located through the syntax tree rather than the text, so they
+// survive the changes users make to the files, and are idempotent.
+package astedit
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pol-cova/GoGinit/internal/routing"
+)
+
+// File is a Go source file being edited
+type File struct {
+	Path string
+
+	fset  *token.FileSet
+	src   []byte
+	file  *ast.File
+	edits []edit
+}
+
+// edit inserts text at an offset of the source, or replaces the text up to
+// end when end is set
+type edit struct {
+	offset, end int
+	text        string
+}
+
+// Router is where routes are registered in a file
+type Router struct {
+	Name string // Variable of the router, or "http" for http.DefaultServeMux
+	Func string // Function registering the routes
+
+	block *ast.BlockStmt
+	after ast.Stmt // Statement the routes are inserted after, nil for the end of block
+}
+
+// ParseFile reads and parses the Go file at path
+func ParseFile(path string) (*File, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, src)
+}
+
+// Parse parses src, read from path
+func Parse(path string, src []byte) (*File, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	return &File{Path: path, fset: fset, src: src, file: f}, nil
+}
+
+// Router finds where the file registers its routes:
+//
+//   - the end of a Register function, on its first parameter, as in the
+//     internal/routes package of the generated projects
+//   - after the last call setting up a router created in a function, such
+//     as r := gin.Default() or mux := http.NewServeMux(), and before the
+//     server starts
+//   - after the last http.HandleFunc or http.Handle call of a function,
+//     registering on http.DefaultServeMux
+func (f *File) Router() (Router, bool) {
+	for _, decl := range f.file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || fn.Name.Name != "Register" || fn.Body == nil {
+			continue
+		}
+		if params := fn.Type.Params.List; len(params) > 0 && len(params[0].Names) > 0 {
+			r := Router{Name: params[0].Names[0].Name, Func: fn.Name.Name, block: fn.Body}
+			if n := len(fn.Body.List); n > 0 {
+				r.after = fn.Body.List[n-1]
+			}
+			return r, true
+		}
+	}
+
+	ctors := f.constructors()
+	httpName := routing.ImportName(f.file, "net/http")
+	for _, decl := range f.file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		for i, stmt := range fn.Body.List {
+			name := assignedRouter(stmt, ctors)
+			if name == "" {
+				continue
+			}
+			r := Router{Name: name, Func: fn.Name.Name, block: fn.Body, after: stmt}
+			for _, later := range fn.Body.List[i+1:] {
+				if setsUp(later, name) {
+					r.after = later
+				}
+			}
+			return r, true
+		}
+
+		if httpName == "" {
+			continue
+		}
+		r := Router{Name: httpName, Func: fn.Name.Name, block: fn.Body}
+		for _, stmt := range fn.Body.List {
+			if call := callOf(stmt); call != nil && isSelector(call.Fun, httpName, "HandleFunc", "Handle") {
+				r.after = stmt
+			}
+		}
+		if r.after != nil {
+			return r, true
+		}
+	}
+	return Router{}, false
+}
+
+// constructors returns the router constructors by local package name
+func (f *File) constructors() map[string][]string {
+	ctors := map[string][]string{}
+	for path, funcs := range routing.Constructors {
+		if name := routing.ImportName(f.file, path); name != "" {
+			ctors[name] = funcs
+		}
+	}
+	return ctors
+}
+
+// assignedRouter returns the variable a statement assigns a new router to
+func assignedRouter(stmt ast.Stmt, ctors map[string][]string) string {
+	assign, ok := stmt.(*ast.AssignStmt)
+	if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+		return ""
+	}
+	ident, ok := assign.Lhs[0].(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	call, ok := assign.Rhs[0].(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	for pkg, funcs := range ctors {
+		if isSelector(call.Fun, pkg, funcs...) {
+			return ident.Name
+		}
+	}
+	return ""
+}
+
+// setsUp reports whether a statement sets up the router: a method call on
+// it, such as r.GET("/path", handler) or r.Use(middleware), or a call taking
+// it, such as routes.Register(r), except those starting the server
+func setsUp(stmt ast.Stmt, router string) bool {
+	call := callOf(stmt)
+	if call == nil {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	for _, prefix := range []string{"Run", "Start", "Listen", "Serve"} {
+		if strings.HasPrefix(sel.Sel.Name, prefix) {
+			return false
+		}
+	}
+	// r.HandleFunc("/path", handler).Methods("GET")
+	if inner, ok := sel.X.(*ast.CallExpr); ok {
+		if innerSel, ok := inner.Fun.(*ast.SelectorExpr); ok {
+			sel = innerSel
+		}
+	}
+	if isIdent(sel.X, router) {
+		return true
+	}
+	for _, arg := range call.Args {
+		if isIdent(arg, router) {
+			return true
+		}
+	}
+	return false
+}
+
+// callOf returns the call of an expression statement
+func callOf(stmt ast.Stmt) *ast.CallExpr {
+	expr, ok := stmt.(*ast.ExprStmt)
+	if !ok {
+		return nil
+	}
+	call, _ := expr.X.(*ast.CallExpr)
+	return call
+}
+
+// isSelector reports whether expr is pkg.Name for one of the names
+func isSelector(expr ast.Expr, pkg string, names ...string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || !isIdent(sel.X, pkg) {
+		return false
+	}
+	for _, name := range names {
+		if sel.Sel.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isIdent(expr ast.Expr, name string) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == name
+}
+
+// AddStatements inserts the statements where the router registers its
+// routes, skipping those already in the function. It returns the number of
+// statements added.
+func (f *File) AddStatements(r Router, stmts ...string) (int, error) {
+	present := map[string]bool{}
+	ast.Inspect(r.block, func(n ast.Node) bool {
+		if stmt, ok := n.(ast.Stmt); ok {
+			present[f.print(stmt)] = true
+		}
+		return true
+	})
+
+	var added []string
+	for _, stmt := range stmts {
+		normalized, err := normalize(stmt)
+		if err != nil {
+			return 0, err
+		}
+		if !present[normalized] {
+			present[normalized] = true
+			added = append(added, stmt)
+		}
+	}
+	if len(added) == 0 {
+		return 0, nil
+	}
+
+	if r.after == nil {
+		f.edits = append(f.edits, edit{offset: f.offset(r.block.Rbrace), text: strings.Join(added, "\n") + "\n"})
+		return len(added), nil
+	}
+	f.edits = append(f.edits, edit{offset: f.lineEnd(r.after.End()), text: "\n" + strings.Join(added, "\n")})
+	return len(added), nil
+}
+
+// WrapHandler wraps the handler a function of r returns with a call of fn,
+// where it passes through the router parameter: func Register(h
+// http.Handler) http.Handler { return Logger(h) } becomes return
+// Logger(fn(h)), so that fn runs after the middleware already there. The
+// handler is left alone when fn already wraps it. It reports whether fn
+// wraps the handler, false without an error when no return statement passes
+// the handler.
+func (f *File) WrapHandler(r Router, fn string) (bool, error) {
+	if _, err := parser.ParseExpr(fn); err != nil {
+		return false, fmt.Errorf("invalid function %q: %w", fn, err)
+	}
+
+	var handler *ast.Ident
+	for _, stmt := range r.block.List {
+		ret, ok := stmt.(*ast.ReturnStmt)
+		if !ok || len(ret.Results) != 1 {
+			continue
+		}
+		wrapped := false
+		ast.Inspect(ret.Results[0], func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				wrapped = wrapped || f.print(n.Fun) == fn
+			case *ast.Ident:
+				if n.Name == r.Name {
+					handler = n
+				}
+			}
+			return true
+		})
+		if wrapped {
+			return true, nil
+		}
+	}
+	if handler == nil {
+		return false, nil
+	}
+	f.edits = append(f.edits, edit{offset: f.offset(handler.Pos()), end: f.offset(handler.End()), text: fn + "(" + handler.Name + ")"})
+	return true, nil
+}
+
+// lineEnd returns the offset after pos and the comment following it on the
+// same line, if any
+func (f *File) lineEnd(pos token.Pos) int {
+	line := f.fset.Position(pos).Line
+	end := pos
+	for _, group := range f.file.Comments {
+		if group.Pos() >= pos && f.fset.Position(group.Pos()).Line == line {
+			end = group.End()
+		}
+	}
+	return f.offset(end)
+}
+
+// AddImport imports the package at path unless the file already does. The
+// import goes to the last import group, which gofmt then sorts.
+func (f *File) AddImport(path string) {
+	if f.imports(path) {
+		return
+	}
+	quoted := strconv.Quote(path)
+
+	var last *ast.GenDecl
+	for _, decl := range f.file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			last = gen
+		}
+	}
+	switch {
+	case last == nil:
+		f.edits = append(f.edits, edit{offset: f.offset(f.file.Name.End()), text: "\n\nimport " + quoted})
+	case last.Lparen.IsValid():
+		offset := f.offset(last.Rparen)
+		for i, e := range f.edits {
+			if e.offset == offset && e.end == 0 {
+				f.edits[i].text += "\t" + quoted + "\n"
+				return
+			}
+		}
+		// A package outside the standard library starts its own group
+		text := "\t" + quoted + "\n"
+		if n := len(last.Specs); n > 0 && standard(last.Specs[n-1].(*ast.ImportSpec).Path.Value) && !standard(quoted) {
+			text = "\n" + text
+		}
+		f.edits = append(f.edits, edit{offset: offset, text: text})
+	default:
+		// A single import is turned into a block, once
+		start, end := f.offset(last.Pos()), f.offset(last.End())
+		for i, e := range f.edits {
+			if e.offset == start && e.end == end {
+				f.edits[i].text = strings.TrimSuffix(e.text, ")") + "\t" + quoted + "\n)"
+				return
+			}
+		}
+		spec := f.src[f.offset(last.Specs[0].Pos()):f.offset(last.Specs[0].End())]
+		f.edits = append(f.edits, edit{offset: start, end: end, text: fmt.Sprintf("import (\n\t%s\n\n\t%s\n)", spec, quoted)})
+	}
+}
+
+// standard reports whether a quoted import path is from the standard
+// library, whose first path element has no dot
+func standard(quoted string) bool {
+	path, err := strconv.Unquote(quoted)
+	if err != nil {
+		return false
+	}
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
+
+// imports reports whether the file imports path or an edit adds it
+func (f *File) imports(path string) bool {
+	for _, spec := range f.file.Imports {
+		if p, err := strconv.Unquote(spec.Path.Value); err == nil && p == path {
+			return true
+		}
+	}
+	for _, e := range f.edits {
+		if strings.Contains(e.text, strconv.Quote(path)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Changed reports whether an edit is pending
+func (f *File) Changed() bool {
+	return len(f.edits) > 0
+}
+
+// Format returns the edited source, formatted with go/format
+func (f *File) Format() ([]byte, error) {
+	edits := append([]edit(nil), f.edits...)
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].offset > edits[j].offset })
+
+	out := string(f.src)
+	for _, e := range edits {
+		end := e.offset
+		if e.end > 0 {
+			end = e.end
+		}
+		out = out[:e.offset] + e.text + out[end:]
+	}
+	formatted, err := format.Source([]byte(out))
+	if err != nil {
+		return nil, fmt.Errorf("edited %s is not valid Go: %w", f.Path, err)
+	}
+	return formatted, nil
+}
+
+// Write formats the edited source and writes it back to the file
+func (f *File) Write() error {
+	src, err := f.Format()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(f.Path, src, 0644)
+}
+
+func (f *File) offset(pos token.Pos) int {
+	return f.fset.Position(pos).Offset
+}
+
+// print returns the source of a node of the file, normalized by the printer
+func (f *File) print(node ast.Node) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, f.fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// normalize parses a statement and prints it as print does
+func normalize(stmt string) (string, error) {
+	src := "package p\nfunc _() {\n" + stmt + "\n}\n"
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return "", fmt.Errorf("invalid statement %q: %w", stmt, err)
+	}
+	body := file.Decls[0].(*ast.FuncDecl).Body
+	if len(body.List) != 1 {
+		return "", fmt.Errorf("invalid statement %q: want a single statement", stmt)
+	}
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, body.List[0]); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
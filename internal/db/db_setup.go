@@ -1,122 +1,148 @@
 package db
 
 import (
+	"context"
 	"fmt"
-	"github.com/pol-cova/GoGinit/config"
-	"log"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
 	"text/template"
+
+	"github.com/pol-cova/GoGinit/config"
+	"github.com/pol-cova/GoGinit/runner"
 )
 
-// Function to install the required Go package
-func installGoPackage(pkg string, projectDir string) {
-	fmt.Printf("Installing Go package: %-25s", pkg)
+// SQLitePackage is the import path of the driver installed by the database
+// setup
+const SQLitePackage = config.SQLiteImportPath
 
-	// Ensure we are in the project directory
-	cmd := exec.Command("go", "get", pkg)
-	cmd.Dir = projectDir
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+// dbGoTemplate is the template for the generated pkg/db/db.go file
+const dbGoTemplate = `package db
+
+import (
+    "database/sql"
+    _ "github.com/mattn/go-sqlite3"
+    "log"
+)
+
+func InitDB() *sql.DB {
+    db, err := sql.Open("sqlite3", "./pkg/db/{{.ProjectName}}.db")
+    if err != nil {
+        log.Fatalf("Failed to connect to the database: %v", err)
+    }
+
+    // Add any schema setup or other initialization here
+
+    return db
+}`
 
-	if err := cmd.Run(); err != nil {
-		log.Fatalf("\nFailed to install package %s: %v", pkg, err)
+// Files returns the files created by SetupDatabase, keyed by their path
+// relative to the project root
+func Files(projectName string) (map[string]string, error) {
+	dbGo, err := renderDBGoFile(projectName)
+	if err != nil {
+		return nil, err
 	}
+	return map[string]string{
+		filepath.Join("pkg", "db", projectName+".db"): "",
+		filepath.Join("pkg", "db", "db.go"):           dbGo,
+	}, nil
+}
 
-	if err := cmd.Run(); err != nil {
-		log.Fatalf("\nFailed to tidy go.mod: %v", err)
+// Function to install the required Go package
+func installGoPackage(ctx context.Context, r runner.Runner, w io.Writer, pkg string, projectDir string) error {
+	fmt.Fprintf(w, "Installing Go package: %-25s", pkg)
+
+	// Ensure we are in the project directory
+	result, err := r.Run(ctx, runner.Go(projectDir, "get", pkg))
+	if err != nil {
+		fmt.Fprintln(w, " [FAILED]")
+		return fmt.Errorf("failed to install package %s: %v, Output: %s", pkg, err, result.Output)
 	}
 
-	fmt.Println(" [OK]")
+	fmt.Fprintln(w, " [OK]")
+	return nil
 }
 
-// SetupDatabase sets up the database structure
-func SetupDatabase(projectName string, setupDB bool) {
+// SetupDatabase sets up the database structure for projectName inside
+// projectDir, which may be a staging directory with a different name.
+// projectDir must already contain a go.mod file. Progress is written to w.
+func SetupDatabase(ctx context.Context, r runner.Runner, w io.Writer, projectDir, projectName string, setupDB bool) error {
 	if !setupDB {
-		fmt.Println("Skipping database setup...")
-		return
+		fmt.Fprintln(w, "Skipping database setup...")
+		return nil
 	}
 
-	fmt.Println("Setting up the database...")
-
-	// Generate the go.mod file if it does not exist
-	if err := config.GenerateGoMod(projectName); err != nil {
-		log.Fatalf("Error generating go.mod file: %v", err)
-	}
+	fmt.Fprintln(w, "Setting up the database...")
 
 	// Install the SQLite package
-	installGoPackage("github.com/mattn/go-sqlite3", projectName)
+	if err := installGoPackage(ctx, r, w, config.SQLiteDependency(), projectDir); err != nil {
+		return err
+	}
 
 	// Define the directories
-	dbDir := filepath.Join(projectName, "pkg", "db")
+	dbDir := filepath.Join(projectDir, "pkg", "db")
 	dbFile := filepath.Join(dbDir, fmt.Sprintf("%s.db", projectName))
 
 	// Check if the db directory exists, if not, create it
 	if _, err := os.Stat(dbDir); os.IsNotExist(err) {
-		fmt.Printf("Creating db directory: %-20s", dbDir)
+		fmt.Fprintf(w, "Creating db directory: %-20s", dbDir)
 		if err := os.MkdirAll(dbDir, 0755); err != nil {
-			log.Fatalf("\nFailed to create db directory: %v", err)
+			return fmt.Errorf("failed to create db directory: %w", err)
 		}
-		fmt.Println(" [OK]")
+		fmt.Fprintln(w, " [OK]")
 	} else {
-		fmt.Println("DB directory already exists [OK]")
+		fmt.Fprintln(w, "DB directory already exists [OK]")
 	}
 
 	// Create the database file
-	fmt.Printf("Creating database file: %-20s", dbFile)
+	fmt.Fprintf(w, "Creating database file: %-20s", filepath.Join(projectName, "pkg", "db", filepath.Base(dbFile)))
 	file, err := os.Create(dbFile)
 	if err != nil {
-		log.Fatalf("\nFailed to create database file: %v", err)
+		return fmt.Errorf("failed to create database file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to create database file: %w", err)
 	}
-	defer file.Close()
 
-	fmt.Println(" [OK]")
+	fmt.Fprintln(w, " [OK]")
 
 	// Optionally generate db.go with init code
-	generateDBGoFile(projectName, dbDir)
+	return generateDBGoFile(w, projectName, dbDir)
 }
 
 // generateDBGoFile generates the db.go file with initialization code
-func generateDBGoFile(projectName, dbDir string) {
+func generateDBGoFile(w io.Writer, projectName, dbDir string) error {
 	dbGoFile := filepath.Join(dbDir, "db.go")
 
-	tmpl := `package db
-
-import (
-    "database/sql"
-    _ "github.com/mattn/go-sqlite3"
-    "log"
-)
-
-func InitDB() *sql.DB {
-    db, err := sql.Open("sqlite3", "./pkg/db/{{.ProjectName}}.db")
-    if err != nil {
-        log.Fatalf("Failed to connect to the database: %v", err)
-    }
-
-    // Add any schema setup or other initialization here
-
-    return db
-}`
+	content, err := renderDBGoFile(projectName)
+	if err != nil {
+		return err
+	}
 
 	// Create or overwrite db.go
-	fmt.Printf("Generating db.go file: %-20s", dbGoFile)
-	file, err := os.Create(dbGoFile)
-	if err != nil {
-		log.Fatalf("\nFailed to create db.go file: %v", err)
+	fmt.Fprintf(w, "Generating db.go file: %-20s", filepath.Join(projectName, "pkg", "db", "db.go"))
+	if err := os.WriteFile(dbGoFile, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to create db.go file: %w", err)
 	}
-	defer file.Close()
+
+	fmt.Fprintln(w, " [OK]")
+	return nil
+}
+
+// renderDBGoFile executes the db.go template for the project
+func renderDBGoFile(projectName string) (string, error) {
+	var sb strings.Builder
 
 	// Parse and execute the template
-	t := template.Must(template.New("dbGo").Parse(tmpl))
-	if err := t.Execute(file, struct {
+	t := template.Must(template.New("dbGo").Parse(dbGoTemplate))
+	if err := t.Execute(&sb, struct {
 		ProjectName string
 	}{
 		ProjectName: projectName,
 	}); err != nil {
-		log.Fatalf("\nFailed to execute template: %v", err)
+		return "", fmt.Errorf("failed to execute template: %w", err)
 	}
-
-	fmt.Println(" [OK]")
+	return sb.String(), nil
 }
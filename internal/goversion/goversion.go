@@ -0,0 +1,48 @@
+// Package goversion compares Go release versions such as 1.22 or 1.22.3, as
+// written in the go directive of a go.mod file.
+package goversion
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// valid matches a release version: 1.N or 1.N.P
+var valid = regexp.MustCompile(`^1\.(0|[1-9][0-9]*)(\.(0|[1-9][0-9]*))?$`)
+
+// IsValid reports whether v is a Go release version such as 1.22 or 1.22.3
+func IsValid(v string) bool {
+	return valid.MatchString(v)
+}
+
+// Compare returns -1, 0 or +1 as a is older than, the same as or newer than
+// b. A missing patch number counts as 0, so 1.22 and 1.22.0 are equal.
+// Both versions must be valid.
+func Compare(a, b string) int {
+	pa, pb := parts(a), parts(b)
+	for i := range pa {
+		switch {
+		case pa[i] < pb[i]:
+			return -1
+		case pa[i] > pb[i]:
+			return +1
+		}
+	}
+	return 0
+}
+
+// AtLeast reports whether v is min or newer. An empty v stands for the
+// latest release and is always new enough.
+func AtLeast(v, min string) bool {
+	return v == "" || Compare(v, min) >= 0
+}
+
+// parts returns the major, minor and patch numbers of v
+func parts(v string) [3]int {
+	var p [3]int
+	for i, field := range strings.SplitN(v, ".", 3) {
+		p[i], _ = strconv.Atoi(field)
+	}
+	return p
+}
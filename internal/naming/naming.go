@@ -0,0 +1,97 @@
+// Package naming turns names given on the command line, such as get_user,
+// getUser or "get user", into Go identifiers and file names.
+package naming
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// initialisms are written in upper case in Go identifiers
+var initialisms = map[string]bool{
+	"API": true, "CSS": true, "DB": true, "HTML": true, "HTTP": true, "HTTPS": true,
+	"ID": true, "IP": true, "JSON": true, "SQL": true, "TCP": true, "UI": true,
+	"URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// Words splits s into lower-case words, at separators and at case changes:
+// getUser, get_user and "HTTP user" give get user and http user
+func Words(s string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := word[len(word)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			// userID splits before I, HTTPServer before S
+			if !unicode.IsUpper(prev) || nextLower {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+// Pascal returns s as an exported Go identifier, such as GetUser or UserID
+func Pascal(s string) string {
+	var sb strings.Builder
+	for _, word := range Words(s) {
+		if upper := strings.ToUpper(word); initialisms[upper] {
+			sb.WriteString(upper)
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(word)
+		sb.WriteRune(unicode.ToUpper(r))
+		sb.WriteString(word[size:])
+	}
+	return sb.String()
+}
+
+// Camel returns s as an unexported Go identifier, such as getUser or userID
+func Camel(s string) string {
+	words := Words(s)
+	if len(words) == 0 {
+		return ""
+	}
+	return words[0] + Pascal(strings.Join(words[1:], " "))
+}
+
+// Snake returns s in snake case, as used for file names: get_user
+func Snake(s string) string {
+	return strings.Join(Words(s), "_")
+}
+
+// Kebab returns s in kebab case, as used in URL paths: get-user
+func Kebab(s string) string {
+	return strings.Join(Words(s), "-")
+}
+
+// Plural returns the plural of the last word of a snake case name, as used
+// for table names: user gives users, order_category order_categories
+func Plural(s string) string {
+	switch {
+	case s == "":
+		return s
+	case strings.HasSuffix(s, "s"), strings.HasSuffix(s, "x"), strings.HasSuffix(s, "z"),
+		strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "sh"):
+		return s + "es"
+	case strings.HasSuffix(s, "y") && len(s) > 1 && !strings.ContainsRune("aeiou", rune(s[len(s)-2])):
+		return s[:len(s)-1] + "ies"
+	}
+	return s + "s"
+}
@@ -0,0 +1,383 @@
+// Package openapi reads and writes OpenAPI 3 documents, in YAML or JSON,
+// keeping the order of their paths and properties so that generated code
+// follows the document.
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is an OpenAPI 3 document, limited to what the generators use
+type Document struct {
+	OpenAPI    string            `yaml:"openapi"`
+	Swagger    string            `yaml:"swagger,omitempty"`
+	Info       Info              `yaml:"info"`
+	Paths      Ordered[PathItem] `yaml:"paths"`
+	Components Components        `yaml:"components,omitempty"`
+}
+
+// Info holds the metadata of the API
+type Info struct {
+	Title       string `yaml:"title"`
+	Description string `yaml:"description,omitempty"`
+	Version     string `yaml:"version"`
+}
+
+// Components holds the reusable objects referenced with $ref
+type Components struct {
+	Schemas       Ordered[*Schema]     `yaml:"schemas,omitempty"`
+	Parameters    Ordered[Parameter]   `yaml:"parameters,omitempty"`
+	RequestBodies Ordered[RequestBody] `yaml:"requestBodies,omitempty"`
+	Responses     Ordered[Response]    `yaml:"responses,omitempty"`
+}
+
+// PathItem holds the operations of a path
+type PathItem struct {
+	Parameters []Parameter `yaml:"parameters,omitempty"`
+	Get        *Operation  `yaml:"get,omitempty"`
+	Put        *Operation  `yaml:"put,omitempty"`
+	Post       *Operation  `yaml:"post,omitempty"`
+	Delete     *Operation  `yaml:"delete,omitempty"`
+	Options    *Operation  `yaml:"options,omitempty"`
+	Head       *Operation  `yaml:"head,omitempty"`
+	Patch      *Operation  `yaml:"patch,omitempty"`
+	Trace      *Operation  `yaml:"trace,omitempty"`
+}
+
+// Operations returns the operations of the path item by HTTP method, in
+// the order of the specification
+func (p PathItem) Operations() Ordered[*Operation] {
+	var ops Ordered[*Operation]
+	for _, op := range []Named[*Operation]{
+		{"GET", p.Get}, {"PUT", p.Put}, {"POST", p.Post}, {"DELETE", p.Delete},
+		{"OPTIONS", p.Options}, {"HEAD", p.Head}, {"PATCH", p.Patch}, {"TRACE", p.Trace},
+	} {
+		if op.Value != nil {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// SetOperation sets the operation of an HTTP method, reporting false when
+// OpenAPI has no field for the method, such as CONNECT
+func (p *PathItem) SetOperation(method string, op *Operation) bool {
+	fields := map[string]**Operation{
+		"GET": &p.Get, "PUT": &p.Put, "POST": &p.Post, "DELETE": &p.Delete,
+		"OPTIONS": &p.Options, "HEAD": &p.Head, "PATCH": &p.Patch, "TRACE": &p.Trace,
+	}
+	field, ok := fields[strings.ToUpper(method)]
+	if ok {
+		*field = op
+	}
+	return ok
+}
+
+// Operation is an API operation on a path
+type Operation struct {
+	OperationID string             `yaml:"operationId,omitempty"`
+	Summary     string             `yaml:"summary,omitempty"`
+	Description string             `yaml:"description,omitempty"`
+	Tags        []string           `yaml:"tags,omitempty"`
+	Parameters  []Parameter        `yaml:"parameters,omitempty"`
+	RequestBody *RequestBody       `yaml:"requestBody,omitempty"`
+	Responses   Ordered[*Response] `yaml:"responses,omitempty"`
+}
+
+// Parameter is a path, query, header or cookie parameter
+type Parameter struct {
+	Ref         string  `yaml:"$ref,omitempty"`
+	Name        string  `yaml:"name,omitempty"`
+	In          string  `yaml:"in,omitempty"`
+	Description string  `yaml:"description,omitempty"`
+	Required    bool    `yaml:"required,omitempty"`
+	Schema      *Schema `yaml:"schema,omitempty"`
+}
+
+// RequestBody is the body of an operation
+type RequestBody struct {
+	Ref         string              `yaml:"$ref,omitempty"`
+	Description string              `yaml:"description,omitempty"`
+	Required    bool                `yaml:"required,omitempty"`
+	Content     Ordered[*MediaType] `yaml:"content,omitempty"`
+}
+
+// Response is a response of an operation, by status code
+type Response struct {
+	Ref         string              `yaml:"$ref,omitempty"`
+	Description string              `yaml:"description,omitempty"`
+	Content     Ordered[*MediaType] `yaml:"content,omitempty"`
+}
+
+// MediaType describes a body by content type
+type MediaType struct {
+	Schema *Schema `yaml:"schema,omitempty"`
+}
+
+// Schema is a JSON schema. Nullable is set both by the nullable keyword of
+// OpenAPI 3.0 and by a "null" type in OpenAPI 3.1.
+type Schema struct {
+	Ref                  string           `yaml:"$ref,omitempty"`
+	Type                 Types            `yaml:"type,omitempty"`
+	Format               string           `yaml:"format,omitempty"`
+	Description          string           `yaml:"description,omitempty"`
+	Nullable             bool             `yaml:"nullable,omitempty"`
+	Enum                 []any            `yaml:"enum,omitempty"`
+	Properties           Ordered[*Schema] `yaml:"properties,omitempty"`
+	Required             []string         `yaml:"required,omitempty"`
+	Items                *Schema          `yaml:"items,omitempty"`
+	AdditionalProperties *Additional      `yaml:"additionalProperties,omitempty"`
+	AllOf                []*Schema        `yaml:"allOf,omitempty"`
+	OneOf                []*Schema        `yaml:"oneOf,omitempty"`
+	AnyOf                []*Schema        `yaml:"anyOf,omitempty"`
+}
+
+// Is reports whether the schema has the type, such as "object"
+func (s *Schema) Is(typ string) bool {
+	for _, t := range s.Type {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNullable reports whether the schema accepts null
+func (s *Schema) IsNullable() bool {
+	return s.Nullable || s.Is("null")
+}
+
+// IsRequired reports whether the object schema requires the property
+func (s *Schema) IsRequired(property string) bool {
+	for _, name := range s.Required {
+		if name == property {
+			return true
+		}
+	}
+	return false
+}
+
+// Types holds the type of a schema, written as a single name or, in
+// OpenAPI 3.1, as a list such as [string, "null"]
+type Types []string
+
+// UnmarshalYAML accepts a type name or a list of them
+func (t *Types) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*t = Types{node.Value}
+		return nil
+	}
+	var types []string
+	if err := node.Decode(&types); err != nil {
+		return err
+	}
+	*t = types
+	return nil
+}
+
+// MarshalYAML writes a single type as a name
+func (t Types) MarshalYAML() (any, error) {
+	if len(t) == 1 {
+		return t[0], nil
+	}
+	return []string(t), nil
+}
+
+// Additional is the additionalProperties of an object schema
+type Additional struct {
+	Allowed bool    // Properties other than those listed are accepted
+	Schema  *Schema // Schema of their values, nil for values of any type
+}
+
+// UnmarshalYAML accepts a boolean or a schema
+func (a *Additional) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&a.Allowed)
+	}
+	a.Allowed, a.Schema = true, new(Schema)
+	return node.Decode(a.Schema)
+}
+
+// MarshalYAML writes the schema of the values, or whether other properties
+// are accepted
+func (a Additional) MarshalYAML() (any, error) {
+	if a.Schema != nil {
+		return a.Schema, nil
+	}
+	return a.Allowed, nil
+}
+
+// Named is an entry of an Ordered mapping
+type Named[T any] struct {
+	Name  string
+	Value T
+}
+
+// Ordered is a mapping decoded in document order
+type Ordered[T any] []Named[T]
+
+// UnmarshalYAML decodes a mapping, keeping the order of its keys
+func (o *Ordered[T]) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected a mapping", node.Line)
+	}
+	entries := make(Ordered[T], 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var value T
+		if err := node.Content[i+1].Decode(&value); err != nil {
+			return err
+		}
+		entries = append(entries, Named[T]{Name: node.Content[i].Value, Value: value})
+	}
+	*o = entries
+	return nil
+}
+
+// MarshalYAML writes the mapping in order
+func (o Ordered[T]) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, entry := range o {
+		var value yaml.Node
+		if err := value.Encode(entry.Value); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: entry.Name}, &value)
+	}
+	return node, nil
+}
+
+// Get returns the value of a key
+func (o Ordered[T]) Get(name string) (T, bool) {
+	for _, entry := range o {
+		if entry.Name == name {
+			return entry.Value, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// Load reads an OpenAPI 3 document from a YAML or JSON file
+func Load(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return doc, nil
+}
+
+// Parse decodes an OpenAPI 3 document. Swagger 2.0 documents are rejected.
+func Parse(data []byte) (*Document, error) {
+	var doc Document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	if doc.Swagger != "" {
+		return nil, fmt.Errorf("Swagger %s documents are not supported, convert the document to OpenAPI 3", doc.Swagger)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q, OpenAPI 3 documents are supported", doc.OpenAPI)
+	}
+	return &doc, nil
+}
+
+// Marshal encodes a document as YAML
+func Marshal(doc *Document) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Ref returns the reference to a component, such as
+// #/components/schemas/Pet
+func Ref(kind, name string) string {
+	return "#/components/" + kind + "/" + name
+}
+
+// ComponentName returns the name of a component referenced by ref, such as
+// Pet for #/components/schemas/Pet. Only references to the components of
+// the document, of the given kind, are supported.
+func ComponentName(ref, kind string) (string, error) {
+	prefix := "#/components/" + kind + "/"
+	name, ok := strings.CutPrefix(ref, prefix)
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return "", fmt.Errorf("unsupported reference %q, only %s<name> references are supported", ref, prefix)
+	}
+	return name, nil
+}
+
+// Parameter resolves a parameter reference
+func (d *Document) Parameter(p Parameter) (Parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	name, err := ComponentName(p.Ref, "parameters")
+	if err != nil {
+		return p, err
+	}
+	resolved, ok := d.Components.Parameters.Get(name)
+	if !ok {
+		return p, fmt.Errorf("parameter %s is not defined", p.Ref)
+	}
+	return resolved, nil
+}
+
+// RequestBody resolves a request body reference
+func (d *Document) RequestBody(b *RequestBody) (*RequestBody, error) {
+	if b == nil || b.Ref == "" {
+		return b, nil
+	}
+	name, err := ComponentName(b.Ref, "requestBodies")
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := d.Components.RequestBodies.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("request body %s is not defined", b.Ref)
+	}
+	return &resolved, nil
+}
+
+// Response resolves a response reference
+func (d *Document) Response(r *Response) (*Response, error) {
+	if r == nil || r.Ref == "" {
+		return r, nil
+	}
+	name, err := ComponentName(r.Ref, "responses")
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := d.Components.Responses.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("response %s is not defined", r.Ref)
+	}
+	return &resolved, nil
+}
+
+// JSONSchema returns the schema of the JSON content of a body, nil when it
+// has none
+func JSONSchema(content Ordered[*MediaType]) *Schema {
+	for _, media := range content {
+		typ := strings.TrimSpace(strings.Split(media.Name, ";")[0])
+		if (typ == "application/json" || strings.HasSuffix(typ, "+json")) && media.Value != nil {
+			return media.Value.Schema
+		}
+	}
+	return nil
+}
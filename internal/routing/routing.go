@@ -0,0 +1,210 @@
+// Package routing knows how every supported framework writes route paths and
+// registers routes, so the generators can write code in its idiom.
+package routing
+
+import (
+	"fmt"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/pol-cova/GoGinit/internal/goversion"
+)
+
+// Methods are the HTTP methods routes can be generated for
+var Methods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+
+// Syntax is the way a framework writes path parameters
+type Syntax int
+
+const (
+	Colon  Syntax = iota // /users/:id
+	Braces               // /users/{id}
+)
+
+// Path is a route path, such as /users/:id, split into segments
+type Path struct {
+	segments []segment
+}
+
+type segment struct {
+	value string // Literal text, or parameter name
+	param bool
+}
+
+// ParsePath parses a route path starting with a slash. Path parameters are
+// written :name or {name}, whatever the framework.
+func ParsePath(s string) (Path, error) {
+	if !strings.HasPrefix(s, "/") {
+		return Path{}, fmt.Errorf("route path %q must start with /", s)
+	}
+
+	var p Path
+	seen := map[string]bool{}
+	for _, elem := range strings.Split(strings.Trim(s, "/"), "/") {
+		if elem == "" {
+			if s != "/" {
+				return Path{}, fmt.Errorf("route path %q has an empty segment", s)
+			}
+			continue
+		}
+
+		name, param := strings.CutPrefix(elem, ":")
+		if !param && strings.HasPrefix(elem, "{") && strings.HasSuffix(elem, "}") {
+			name, param = elem[1:len(elem)-1], true
+		}
+		if param {
+			if !token.IsIdentifier(name) {
+				return Path{}, fmt.Errorf("invalid parameter %q in route path %q", elem, s)
+			}
+			if seen[name] {
+				return Path{}, fmt.Errorf("duplicate parameter %q in route path %q", name, s)
+			}
+			seen[name] = true
+		} else if strings.ContainsAny(elem, ":{}*? \t") {
+			return Path{}, fmt.Errorf("invalid segment %q in route path %q", elem, s)
+		}
+		p.segments = append(p.segments, segment{value: name, param: param})
+	}
+	return p, nil
+}
+
+// Params returns the names of the path parameters, in order
+func (p Path) Params() []string {
+	var params []string
+	for _, seg := range p.segments {
+		if seg.param {
+			params = append(params, seg.value)
+		}
+	}
+	return params
+}
+
+// Format writes the path with the given parameter syntax
+func (p Path) Format(syntax Syntax) string {
+	return p.format(func(i int, name string) string {
+		if syntax == Braces {
+			return "{" + name + "}"
+		}
+		return ":" + name
+	})
+}
+
+// Sample writes the path with the parameters replaced by 1, 2 and so on,
+// the values returned by SampleValue
+func (p Path) Sample() string {
+	return p.format(func(i int, name string) string {
+		return SampleValue(i)
+	})
+}
+
+// SampleValue returns the value Sample gives to the i-th parameter
+func SampleValue(i int) string {
+	return strconv.Itoa(i + 1)
+}
+
+func (p Path) format(param func(i int, name string) string) string {
+	var sb strings.Builder
+	n := 0
+	for _, seg := range p.segments {
+		sb.WriteString("/")
+		if seg.param {
+			sb.WriteString(param(n, seg.value))
+			n++
+			continue
+		}
+		sb.WriteString(seg.value)
+	}
+	if sb.Len() == 0 {
+		return "/"
+	}
+	return sb.String()
+}
+
+// Form is the shape of the statement registering a route
+type Form int
+
+const (
+	UpperMethod   Form = iota // r.GET("/path", handler)
+	TitleMethod               // r.Get("/path", handler)
+	MuxMethods                // r.HandleFunc("/path", handler).Methods("GET")
+	PackageFunc               // fuego.Get(s, "/path", handler)
+	MethodPattern             // mux.HandleFunc("GET /path", handler)
+	AnyMethod                 // mux.HandleFunc("/path", handler), matching every method
+)
+
+// Style describes how a framework registers routes in the Register function
+// of the generated internal/routes package
+type Style struct {
+	Router  string // Name of the router parameter of routes.Register
+	Package string // Package name of the registration functions, for PackageFunc
+	Form    Form
+	Syntax  Syntax
+
+	// HandlerFirst is set when route middleware follows the handler, as in
+	// e.GET("/path", handler, middleware), instead of preceding it
+	HandlerFirst bool
+}
+
+// styles holds the route style of every framework with generators
+var styles = map[string]Style{
+	"gin":     {"r", "", UpperMethod, Colon, false},
+	"echo":    {"e", "", UpperMethod, Colon, true},
+	"fiber":   {"app", "", TitleMethod, Colon, false},
+	"martini": {"m", "", TitleMethod, Colon, false},
+	"chi":     {"r", "", TitleMethod, Braces, false},
+	"mux":     {"r", "", MuxMethods, Braces, false},
+	"gofr":    {"app", "", UpperMethod, Braces, false},
+	"fuego":   {"s", "fuego", PackageFunc, Braces, false},
+	"default": {"mux", "", MethodPattern, Braces, false},
+}
+
+// Lookup returns the route style of a framework in a module targeting
+// goVersion. Before Go 1.22 ServeMux patterns have no methods and no
+// parameters.
+func Lookup(framework, goVersion string) (Style, bool) {
+	style, ok := styles[framework]
+	if ok && style.Form == MethodPattern && !goversion.AtLeast(goVersion, "1.22") {
+		style.Form = AnyMethod
+	}
+	return style, ok
+}
+
+// Check reports whether a route with the path can be registered
+func (s Style) Check(path Path) error {
+	if s.Form == AnyMethod && len(path.Params()) > 0 {
+		return fmt.Errorf("path parameters need Go 1.22 or later with net/http")
+	}
+	return nil
+}
+
+// Path writes the path in the syntax of the framework
+func (s Style) Path(path Path) string {
+	return path.Format(s.Syntax)
+}
+
+// Statement returns the statement registering handler for the method and
+// path on the router of routes.Register
+func (s Style) Statement(method string, path Path, handler string) string {
+	method = strings.ToUpper(method)
+	p := strconv.Quote(s.Path(path))
+	switch s.Form {
+	case TitleMethod:
+		return fmt.Sprintf("%s.%s(%s, %s)", s.Router, title(method), p, handler)
+	case MuxMethods:
+		return fmt.Sprintf("%s.HandleFunc(%s, %s).Methods(%q)", s.Router, p, handler, method)
+	case PackageFunc:
+		return fmt.Sprintf("%s.%s(%s, %s, %s)", s.Package, title(method), s.Router, p, handler)
+	case MethodPattern:
+		return fmt.Sprintf("%s.HandleFunc(%q, %s)", s.Router, method+" "+s.Path(path), handler)
+	case AnyMethod:
+		return fmt.Sprintf("%s.HandleFunc(%s, %s)", s.Router, p, handler)
+	default:
+		return fmt.Sprintf("%s.%s(%s, %s)", s.Router, method, p, handler)
+	}
+}
+
+// title turns an HTTP method such as GET into Get
+func title(method string) string {
+	return method[:1] + strings.ToLower(method[1:])
+}
@@ -0,0 +1,77 @@
+package routing
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path   string
+		colon  string
+		braces string
+		want   string // Part of the error, empty when valid
+	}{
+		{path: "/", colon: "/", braces: "/"},
+		{path: "/users/:id", colon: "/users/:id", braces: "/users/{id}"},
+		{path: "/users/{id}/posts/{post}/", colon: "/users/:id/posts/:post", braces: "/users/{id}/posts/{post}"},
+		{path: "users", want: "must start with /"},
+		{path: "/users//posts", want: "empty segment"},
+		{path: "/users/:1d", want: "invalid parameter"},
+		{path: "/users/:id/:id", want: "duplicate parameter"},
+		{path: "/files/*", want: "invalid segment"},
+	}
+	for _, tt := range tests {
+		p, err := ParsePath(tt.path)
+		if tt.want != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParsePath(%q) = %v, want an error containing %s", tt.path, err, tt.want)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePath(%q): %v", tt.path, err)
+			continue
+		}
+		if got := p.Format(Colon); got != tt.colon {
+			t.Errorf("ParsePath(%q).Format(Colon) = %s, want %s", tt.path, got, tt.colon)
+		}
+		if got := p.Format(Braces); got != tt.braces {
+			t.Errorf("ParsePath(%q).Format(Braces) = %s, want %s", tt.path, got, tt.braces)
+		}
+	}
+}
+
+func TestStatement(t *testing.T) {
+	path, err := ParsePath("/users/:id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		framework string
+		goVersion string
+		want      string
+	}{
+		{framework: "gin", want: `r.GET("/users/:id", handlers.GetUser)`},
+		{framework: "chi", want: `r.Get("/users/{id}", handlers.GetUser)`},
+		{framework: "mux", want: `r.HandleFunc("/users/{id}", handlers.GetUser).Methods("GET")`},
+		{framework: "fuego", want: `fuego.Get(s, "/users/{id}", handlers.GetUser)`},
+		{framework: "default", goVersion: "1.22", want: `mux.HandleFunc("GET /users/{id}", handlers.GetUser)`},
+		{framework: "default", goVersion: "1.21", want: `mux.HandleFunc("/users/{id}", handlers.GetUser)`},
+	}
+	for _, tt := range tests {
+		style, ok := Lookup(tt.framework, tt.goVersion)
+		if !ok {
+			t.Fatalf("no style for %s", tt.framework)
+		}
+		if got := style.Statement("get", path, "handlers.GetUser"); got != tt.want {
+			t.Errorf("%s %s: Statement = %s, want %s", tt.framework, tt.goVersion, got, tt.want)
+		}
+	}
+
+	// Before Go 1.22 ServeMux patterns have no parameters
+	style, _ := Lookup("default", "1.21")
+	if err := style.Check(path); err == nil {
+		t.Error("Check accepted a path parameter before Go 1.22")
+	}
+}
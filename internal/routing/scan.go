@@ -0,0 +1,421 @@
+package routing
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// AnyMethodName is the Method of the routes matching every HTTP method
+const AnyMethodName = "ANY"
+
+// Route is a route registration found in source code
+type Route struct {
+	Method  string `json:"method"`  // HTTP method, or AnyMethodName
+	Path    string `json:"path"`    // Path in the syntax of the framework, with the prefix of its group
+	Handler string `json:"handler"` // Source of the handler expression
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+}
+
+// scanMethods are the HTTP methods recognized in registrations
+var scanMethods = append([]string{"HEAD", "OPTIONS", "CONNECT", "TRACE"}, Methods...)
+
+// Constructors are the functions returning a router, by import path
+var Constructors = map[string][]string{
+	"github.com/gin-gonic/gin":      {"Default", "New"},
+	"github.com/labstack/echo/v4":   {"New"},
+	"github.com/gofiber/fiber/v2":   {"New"},
+	"github.com/gofiber/fiber/v3":   {"New"},
+	"github.com/go-martini/martini": {"Classic"},
+	"github.com/go-chi/chi/v5":      {"NewRouter"},
+	"github.com/gorilla/mux":        {"NewRouter"},
+	"gofr.dev/pkg/gofr":             {"New"},
+	"github.com/go-fuego/fuego":     {"NewServer"},
+	"net/http":                      {"NewServeMux"},
+}
+
+// routerTypes are the types of the routers and router groups, by import
+// path, for the parameters of the functions registering routes
+var routerTypes = map[string][]string{
+	"github.com/gin-gonic/gin":      {"Engine", "RouterGroup", "IRouter", "IRoutes"},
+	"github.com/labstack/echo/v4":   {"Echo", "Group"},
+	"github.com/gofiber/fiber/v2":   {"App", "Router", "Group"},
+	"github.com/gofiber/fiber/v3":   {"App", "Router", "Group"},
+	"github.com/go-martini/martini": {"ClassicMartini", "Martini", "Router"},
+	"github.com/go-chi/chi/v5":      {"Router", "Mux"},
+	"gofr.dev/pkg/gofr":             {"App"},
+}
+
+// ImportName returns the name f uses for the imported package, empty when
+// it does not import it
+func ImportName(f *ast.File, path string) string {
+	for _, spec := range f.Imports {
+		if p, err := strconv.Unquote(spec.Path.Value); err != nil || p != path {
+			continue
+		}
+		if spec.Name != nil {
+			if spec.Name.Name == "_" || spec.Name.Name == "." {
+				return ""
+			}
+			return spec.Name.Name
+		}
+		name := path[strings.LastIndex(path, "/")+1:]
+		if strings.HasPrefix(name, "v") && strings.Trim(name[1:], "0123456789") == "" && strings.Contains(path, "/") {
+			// Major version suffix, such as echo/v4
+			trimmed := path[:strings.LastIndex(path, "/")]
+			name = trimmed[strings.LastIndex(trimmed, "/")+1:]
+		}
+		return name
+	}
+	return ""
+}
+
+// Scan returns the routes a file registers in the style of its framework,
+// in source order. Router groups are followed: r.Group("/api") in gin, echo
+// and fiber, r.Route("/api", func(r chi.Router) {...}) in chi and martini,
+// r.PathPrefix("/api").Subrouter() in gorilla/mux and fuego.Group(s, "/api").
+//
+// Method names such as Get are common, so in the UpperMethod and TitleMethod
+// styles only the calls on known routers are routes: the first parameter of
+// Register, the parameters of a router type, the routers returned by the
+// framework's constructors and their groups.
+func (s Style) Scan(fset *token.FileSet, f *ast.File) []Route {
+	sc := scanner{style: s, fset: fset, ctors: byImportName(f, Constructors), types: byImportName(f, routerTypes)}
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+			sc.scan(fn.Body, sc.params(fn))
+		}
+	}
+	return sc.routes
+}
+
+type scanner struct {
+	style  Style
+	fset   *token.FileSet
+	ctors  map[string][]string // Router constructors by package name
+	types  map[string][]string // Router types by package name
+	routes []Route
+}
+
+// byImportName returns the names of m for the packages f imports, keyed by
+// the name f uses for them
+func byImportName(f *ast.File, m map[string][]string) map[string][]string {
+	names := map[string][]string{}
+	for path, funcs := range m {
+		if name := ImportName(f, path); name != "" {
+			names[name] = funcs
+		}
+	}
+	return names
+}
+
+// params returns the router parameters of fn: the first parameter of
+// Register, as in the internal/routes package of the generated projects,
+// and the parameters of a router type
+func (sc *scanner) params(fn *ast.FuncDecl) map[string]string {
+	routers := map[string]string{}
+	for i, field := range fn.Type.Params.List {
+		register := i == 0 && fn.Recv == nil && fn.Name.Name == "Register"
+		if !register && !sc.isRouterType(field.Type) {
+			continue
+		}
+		for j, ident := range field.Names {
+			if !register || j == 0 {
+				routers[ident.Name] = ""
+			}
+		}
+	}
+	return routers
+}
+
+// isRouterType reports whether expr is a router type, such as *gin.Engine
+// or chi.Router
+func (sc *scanner) isRouterType(expr ast.Expr) bool {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	for _, typ := range sc.types[name(sel.X)] {
+		if sel.Sel.Name == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// constructed reports whether expr creates a router, such as gin.Default()
+func (sc *scanner) constructed(expr ast.Expr) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	for _, fn := range sc.ctors[name(sel.X)] {
+		if sel.Sel.Name == fn {
+			return true
+		}
+	}
+	return false
+}
+
+// tracked reports whether the style only registers routes on known routers
+func (sc *scanner) tracked() bool {
+	return sc.style.Form == UpperMethod || sc.style.Form == TitleMethod
+}
+
+// router returns the path prefix of the router expr refers to, and whether
+// it is a router. In the untracked styles every expression is one.
+func (sc *scanner) router(expr ast.Expr, routers map[string]string) (string, bool) {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if prefix, ok := routers[expr.Name]; ok {
+			return prefix, true
+		}
+	case *ast.CallExpr:
+		// r.With(middleware).Get(...) in chi, r.Group("/api").GET(...)
+		if sel, ok := expr.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "With" {
+			return sc.router(sel.X, routers)
+		}
+		if prefix, ok := sc.group(expr, routers); ok {
+			return prefix, true
+		}
+	}
+	return "", !sc.tracked()
+}
+
+// scan collects the routes registered in node. routers holds the path prefix
+// of the router variables, by name.
+func (sc *scanner) scan(node ast.Node, routers map[string]string) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if len(n.Lhs) == 1 && len(n.Rhs) == 1 {
+				if ident, ok := n.Lhs[0].(*ast.Ident); ok {
+					if prefix, ok := sc.group(n.Rhs[0], routers); ok {
+						routers[ident.Name] = prefix
+					} else if sc.constructed(n.Rhs[0]) {
+						routers[ident.Name] = ""
+					}
+				}
+			}
+		case *ast.CallExpr:
+			if sc.subrouter(n, routers) {
+				return false
+			}
+			if route, ok := sc.route(n, routers); ok {
+				sc.routes = append(sc.routes, route)
+				// The inner call of r.HandleFunc(...).Methods(...) is part of the route
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// group returns the path prefix of a router group created by expr
+func (sc *scanner) group(expr ast.Expr, routers map[string]string) (string, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return "", false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+	switch sel.Sel.Name {
+	case "Group":
+		// fuego.Group(s, "/api")
+		if sc.style.Form == PackageFunc && isName(sel.X, sc.style.Package) && len(call.Args) >= 2 {
+			if path, ok := stringLit(call.Args[1]); ok {
+				return join(routers[name(call.Args[0])], path), true
+			}
+			return "", false
+		}
+		// r.Group("/api")
+		if len(call.Args) >= 1 {
+			if path, ok := stringLit(call.Args[0]); ok {
+				if prefix, ok := sc.router(sel.X, routers); ok {
+					return join(prefix, path), true
+				}
+			}
+		}
+	case "Subrouter":
+		// r.PathPrefix("/api").Subrouter()
+		if inner, ok := sel.X.(*ast.CallExpr); ok {
+			if innerSel, ok := inner.Fun.(*ast.SelectorExpr); ok && innerSel.Sel.Name == "PathPrefix" && len(inner.Args) == 1 {
+				if path, ok := stringLit(inner.Args[0]); ok {
+					return join(routers[name(innerSel.X)], path), true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// subrouter scans the function literal of r.Route("/api", func(r chi.Router)
+// {...}) or r.Group("/api", func(r martini.Router) {...}) with the prefix of
+// the group, and reports whether call is one
+func (sc *scanner) subrouter(call *ast.CallExpr, routers map[string]string) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || (sel.Sel.Name != "Route" && sel.Sel.Name != "Group") || len(call.Args) < 2 {
+		return false
+	}
+	path, ok := stringLit(call.Args[0])
+	if !ok {
+		return false
+	}
+	lit, ok := call.Args[1].(*ast.FuncLit)
+	if !ok {
+		return false
+	}
+	params := lit.Type.Params.List
+	if len(params) == 0 || len(params[0].Names) == 0 {
+		return false
+	}
+	prefix, ok := sc.router(sel.X, routers)
+	if !ok {
+		return false
+	}
+
+	inner := make(map[string]string, len(routers)+1)
+	for k, v := range routers {
+		inner[k] = v
+	}
+	inner[params[0].Names[0].Name] = join(prefix, path)
+	sc.scan(lit.Body, inner)
+	return true
+}
+
+// route returns the route registered by call, if it registers one
+func (sc *scanner) route(call *ast.CallExpr, routers map[string]string) (Route, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return Route{}, false
+	}
+
+	var method string
+	var router ast.Expr
+	var args []ast.Expr
+	switch sc.style.Form {
+	case UpperMethod, TitleMethod:
+		method, ok = sc.methodName(sel.Sel.Name)
+		router, args = sel.X, call.Args
+	case PackageFunc:
+		if !isName(sel.X, sc.style.Package) || len(call.Args) < 1 {
+			return Route{}, false
+		}
+		method, ok = sc.methodName(sel.Sel.Name)
+		router, args = call.Args[0], call.Args[1:]
+	case MuxMethods:
+		// r.HandleFunc("/path", handler).Methods("GET", "POST")
+		method = AnyMethodName
+		if sel.Sel.Name == "Methods" {
+			inner, isCall := sel.X.(*ast.CallExpr)
+			if !isCall || len(call.Args) == 0 {
+				return Route{}, false
+			}
+			var methods []string
+			for _, arg := range call.Args {
+				if m, isLit := stringLit(arg); isLit {
+					methods = append(methods, strings.ToUpper(m))
+				}
+			}
+			method = strings.Join(methods, ",")
+			if sel, ok = inner.Fun.(*ast.SelectorExpr); !ok {
+				return Route{}, false
+			}
+			call = inner
+		}
+		ok = sel.Sel.Name == "HandleFunc" || sel.Sel.Name == "Handle"
+		router, args = sel.X, call.Args
+	case MethodPattern, AnyMethod:
+		ok = sel.Sel.Name == "HandleFunc" || sel.Sel.Name == "Handle"
+		router, args = sel.X, call.Args
+	}
+	if !ok || len(args) < 2 {
+		return Route{}, false
+	}
+	path, ok := stringLit(args[0])
+	if !ok {
+		return Route{}, false
+	}
+	if sc.style.Form == MethodPattern || sc.style.Form == AnyMethod {
+		method = AnyMethodName
+		if m, rest, found := strings.Cut(path, " "); found {
+			method, path = m, strings.TrimSpace(rest)
+		}
+	}
+	if !strings.HasPrefix(path, "/") {
+		return Route{}, false
+	}
+	prefix, ok := sc.router(router, routers)
+	if !ok {
+		return Route{}, false
+	}
+
+	handler := args[len(args)-1]
+	if sc.style.HandlerFirst {
+		handler = args[1]
+	}
+	pos := sc.fset.Position(call.Pos())
+	return Route{
+		Method:  method,
+		Path:    join(prefix, path),
+		Handler: types.ExprString(handler),
+		File:    pos.Filename,
+		Line:    pos.Line,
+	}, true
+}
+
+// methodName returns the HTTP method of a registration function name, such
+// as GET or Get, and AnyMethodName for Any and All
+func (sc *scanner) methodName(fn string) (string, bool) {
+	if fn == "Any" || fn == "All" {
+		return AnyMethodName, true
+	}
+	for _, m := range scanMethods {
+		if sc.style.Form == UpperMethod && fn == m || sc.style.Form != UpperMethod && fn == title(m) {
+			return m, true
+		}
+	}
+	return "", false
+}
+
+// join appends a route path to the prefix of its group
+func join(prefix, path string) string {
+	if prefix == "" {
+		return path
+	}
+	return strings.TrimSuffix(prefix, "/") + path
+}
+
+// name returns the name of an identifier, empty for other expressions
+func name(expr ast.Expr) string {
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+func isName(expr ast.Expr, n string) bool {
+	return n != "" && name(expr) == n
+}
+
+// stringLit returns the value of a string literal
+func stringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
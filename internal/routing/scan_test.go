@@ -0,0 +1,171 @@
+package routing
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		framework string
+		src       string
+		want      []string // Method, path and handler of the routes
+	}{
+		{
+			framework: "gin",
+			src: `package routes
+
+import "github.com/gin-gonic/gin"
+
+func Register(r *gin.Engine, client *http.Client) {
+	r.GET("/", handlers.Home)
+	api := r.Group("/api")
+	api.POST("/users", users.Create)
+	api.Group("/v2").Any("/ping", ping)
+	client.Get("/not/a/route")
+}
+
+func main() {
+	e := gin.Default()
+	e.DELETE("/users/:id", users.Delete)
+}
+`,
+			want: []string{"GET / handlers.Home", "POST /api/users users.Create", "ANY /api/v2/ping ping", "DELETE /users/:id users.Delete"},
+		},
+		{
+			framework: "echo",
+			src: `package routes
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+func Register(e *echo.Echo) {
+	e.GET("/users", users.List, auth)
+}
+`,
+			want: []string{"GET /users users.List"},
+		},
+		{
+			framework: "chi",
+			src: `package routes
+
+import "github.com/go-chi/chi/v5"
+
+func Register(r chi.Router) {
+	r.With(auth).Get("/me", me)
+	r.Route("/users", func(r chi.Router) {
+		r.Get("/{id}", users.Get)
+	})
+	cache.Get("/key")
+}
+`,
+			want: []string{"GET /me me", "GET /users/{id} users.Get"},
+		},
+		{
+			framework: "mux",
+			src: `package routes
+
+func Register(r *mux.Router) {
+	r.HandleFunc("/users", users.List).Methods("GET", "head")
+	api := r.PathPrefix("/api").Subrouter()
+	api.Handle("/health", health)
+}
+`,
+			want: []string{"GET,HEAD /users users.List", "ANY /api/health health"},
+		},
+		{
+			framework: "fuego",
+			src: `package routes
+
+import "github.com/go-fuego/fuego"
+
+func Register(s *fuego.Server) {
+	api := fuego.Group(s, "/api")
+	fuego.Post(api, "/users", users.Create)
+}
+`,
+			want: []string{"POST /api/users users.Create"},
+		},
+		{
+			framework: "default",
+			src: `package routes
+
+func Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /users/{id}", users.Get)
+	mux.Handle("/static/", files)
+	mux.HandleFunc("relative", ignored)
+}
+`,
+			want: []string{"GET /users/{id} users.Get", "ANY /static/ files"},
+		},
+		{
+			// Known limitation: a router kept in a struct field is not followed
+			framework: "gin",
+			src: `package server
+
+import "github.com/gin-gonic/gin"
+
+type Server struct{ router *gin.Engine }
+
+func (s *Server) routes() {
+	s.router.GET("/missed", missed)
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		style, ok := Lookup(tt.framework, "1.22")
+		if !ok {
+			t.Fatalf("no style for %s", tt.framework)
+		}
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "routes.go", tt.src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, route := range style.Scan(fset, f) {
+			got = append(got, route.Method+" "+route.Path+" "+route.Handler)
+			if route.File != "routes.go" || route.Line == 0 {
+				t.Errorf("%s: route %s at %s:%d", tt.framework, route.Path, route.File, route.Line)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: routes = %q, want %q", tt.framework, got, tt.want)
+		}
+	}
+}
+
+func TestImportName(t *testing.T) {
+	src := `package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	fiber "github.com/gofiber/fiber/v2"
+	chi "github.com/go-chi/chi/v5"
+	_ "github.com/gin-gonic/gin"
+	"net/http"
+)
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "routes.go", src, parser.ImportsOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"github.com/labstack/echo/v4": "echo",
+		"github.com/gofiber/fiber/v2": "fiber",
+		"github.com/go-chi/chi/v5":    "chi",
+		"github.com/gin-gonic/gin":    "",
+		"net/http":                    "http",
+		"github.com/gorilla/mux":      "",
+	}
+	for path, want := range tests {
+		if got := ImportName(f, path); got != want {
+			t.Errorf("ImportName(%s) = %q, want %q", path, got, want)
+		}
+	}
+}
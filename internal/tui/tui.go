@@ -5,31 +5,101 @@ import (
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/pol-cova/GoGinit/config"
+	"strings"
+)
+
+// Wizard steps, in the order they are asked
+const (
+	stepName = iota
+	stepModule
+	stepFramework
+	stepDB
 )
 
 // Define the model struct
 type model struct {
-	projectName string
-	framework   string
-	choices     []string
-	dbChoices   []string
-	cursor      int
-	step        int    // To track which step we are in
-	input       string // To store user input for project name
-	setupDB     bool   // To store the user's choice for setting up the database
+	projectName  string
+	modulePath   string
+	modulePrefix string // Prefix used to suggest a module path
+	framework    string
+	choices      []config.FrameworkConfig
+	dbChoices    []string
+	cursor       int
+	step         int    // To track which step we are in
+	input        string // To store user input for the text steps
+	setupDB      bool   // To store the user's choice for setting up the database
+	askDB        bool   // Whether the database step still needs an answer
+	done         bool   // Set once every step has been answered
+	err          string // Validation error shown below the text input
+}
+
+// Answers holds the values collected by the wizard. Fields that are already
+// set when passed to GetUserInput are not asked for again.
+type Answers struct {
+	ProjectName  string
+	ModulePath   string
+	ModulePrefix string // Only used to suggest a module path, never asked for
+	Framework    string
+	SetupDB      bool
+	DBAnswered   bool // SetupDB holds a real answer rather than the zero value
 }
 
 // Initialize the model with choices
-func initialModel() model {
-	return model{
-		choices:   []string{"echo", "gin", "fiber", "martini", "chi", "mux", "gofr", "fuego", "default"},
-		dbChoices: []string{"Yes", "No"},
-		step:      0,
+func initialModel(preset Answers) model {
+	m := model{
+		projectName:  preset.ProjectName,
+		modulePath:   preset.ModulePath,
+		modulePrefix: preset.ModulePrefix,
+		framework:    preset.Framework,
+		choices:      config.Frameworks(),
+		dbChoices:    []string{"Yes", "No"},
+		setupDB:      preset.SetupDB,
+		askDB:        !preset.DBAnswered,
+		step:         stepName,
+	}
+	m.nextStep()
+	return m
+}
+
+// nextStep advances to the first step that has not been answered yet
+func (m *model) nextStep() {
+	m.cursor = 0
+	if m.step == stepName && m.projectName != "" {
+		m.step = stepModule
+		// Suggest a module path built from the prefix and the project name
+		m.input = m.projectName
+		if m.modulePrefix != "" {
+			m.input = strings.TrimSuffix(m.modulePrefix, "/") + "/" + m.projectName
+		}
+	}
+	if m.step == stepModule && m.modulePath != "" {
+		m.step = stepFramework
+	}
+	if m.step == stepFramework && m.framework != "" {
+		m.step = stepDB
+	}
+	if m.step == stepDB && !m.askDB {
+		m.done = true
 	}
 }
 
+// options returns the number of choices in the current step
+func (m model) options() int {
+	switch m.step {
+	case stepFramework:
+		return len(m.choices)
+	case stepDB:
+		return len(m.dbChoices)
+	}
+	return 0
+}
+
 // Init is the initialization method
 func (m model) Init() tea.Cmd {
+	if m.done {
+		return tea.Quit
+	}
 	return nil
 }
 
@@ -38,51 +108,92 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "ctrl+c", "q":
+		case "ctrl+c":
 			return m, tea.Quit
+		case "q":
+			if !m.isTextStep() {
+				return m, tea.Quit
+			}
+			m.typeRunes(msg)
 		case "up":
 			if m.cursor > 0 {
 				m.cursor--
 			}
 		case "down":
-			if m.cursor < len(m.choices)-1 {
+			if m.cursor < m.options()-1 {
 				m.cursor++
 			}
 		case "enter":
-			if m.step == 0 {
-				// Save the project name
+			if m.step == stepName {
+				// Validate and save the project name
+				if err := validateProjectName(m.input); err != nil {
+					m.err = err.Error()
+					return m, nil
+				}
 				m.projectName = m.input
-				if m.projectName == "" {
-					fmt.Println("Project name cannot be empty.")
+				m.err = ""
+				// Move to the next step (module path)
+				m.nextStep()
+			} else if m.step == stepModule {
+				// Validate and save the module path
+				if err := config.ValidateModulePath(m.input); err != nil {
+					m.err = err.Error()
 					return m, nil
 				}
+				m.modulePath = m.input
+				m.err = ""
 				// Move to the next step (framework selection)
-				m.step = 1
-				m.cursor = 0
-			} else if m.step == 1 {
+				m.nextStep()
+			} else if m.step == stepFramework {
 				// Save the selected framework
-				m.framework = m.choices[m.cursor]
+				m.framework = m.choices[m.cursor].Name
 				// Move to the next step (DB selection)
-				m.step = 2
-				m.cursor = 0
-			} else if m.step == 2 {
+				m.nextStep()
+			} else if m.step == stepDB {
 				m.setupDB = m.dbChoices[m.cursor] == "Yes"
+				m.askDB = false
+				m.nextStep()
+			}
+			if m.done {
 				// Exit the TUI after completing the selection
 				return m, tea.Quit
 			}
 		case "backspace":
-			if m.step == 0 && len(m.input) > 0 {
+			if m.isTextStep() && len(m.input) > 0 {
 				m.input = m.input[:len(m.input)-1]
+				m.err = ""
 			}
 		default:
-			if m.step == 0 {
-				m.input += msg.String()
-			}
+			m.typeRunes(msg)
 		}
 	}
 	return m, nil
 }
 
+// typeRunes appends typed characters to the input of a text step. Other
+// keys, such as "shift+tab", are ignored rather than added by name.
+func (m *model) typeRunes(msg tea.KeyMsg) {
+	if !m.isTextStep() || msg.Alt || (msg.Type != tea.KeyRunes && msg.Type != tea.KeySpace) {
+		return
+	}
+	m.input += string(msg.Runes)
+	m.err = ""
+}
+
+// validateProjectName checks the typed project name and that it does not
+// point to an existing non-empty directory
+func validateProjectName(name string) error {
+	if err := config.ValidateProjectName(name); err != nil {
+		return err
+	}
+	return config.CheckProjectDir(name)
+}
+
+// isTextStep reports whether the current step reads typed text
+func (m model) isTextStep() bool {
+	return m.step == stepName || m.step == stepModule
+}
+
 // View renders the UI with enhanced styling
 func (m model) View() string {
 	var s string
@@ -96,36 +207,36 @@ func (m model) View() string {
 	selectedChoiceStyle := lipgloss.NewStyle().
 		Render("👉 ")
 
-	// Define color styles for each choice
-	choiceStyles := []lipgloss.Style{
-		lipgloss.NewStyle().Foreground(lipgloss.Color("4")),  // echo
-		lipgloss.NewStyle().Foreground(lipgloss.Color("2")),  // gin
-		lipgloss.NewStyle().Foreground(lipgloss.Color("3")),  // fiber
-		lipgloss.NewStyle().Foreground(lipgloss.Color("6")),  // martini
-		lipgloss.NewStyle().Foreground(lipgloss.Color("5")),  // chi
-		lipgloss.NewStyle().Foreground(lipgloss.Color("13")), // mux
-		lipgloss.NewStyle().Foreground(lipgloss.Color("9")),  // gofr
-		lipgloss.NewStyle().Foreground(lipgloss.Color("9")),  // fuego
-		lipgloss.NewStyle().Foreground(lipgloss.Color("8")),  // default
-	}
-
-	if m.step == 0 {
+	if m.step == stepName {
 		// Prompt for project name
 		s = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("12")).
 			Render("Enter project name: " + m.input)
-	} else if m.step == 1 {
+	} else if m.step == stepModule {
+		// Prompt for module path
+		s = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("12")).
+			Render("Enter module path: " + m.input)
+	}
+	if m.isTextStep() && m.err != "" {
+		s += "\n" + lipgloss.NewStyle().
+			Foreground(lipgloss.Color("9")).
+			Render(m.err)
+	} else if m.step == stepFramework {
 		// Framework selection
 		s = headerStyle + "\n\n"
 		for i, choice := range m.choices {
 			if m.cursor == i {
-				s += selectedChoiceStyle + choice + "\n"
+				s += selectedChoiceStyle + choice.Name + "\n"
 			} else {
-				// Apply color style for each choice
-				s += choiceStyles[i].Render(choice) + "\n"
+				// Apply the registered color for each choice
+				s += lipgloss.NewStyle().Foreground(lipgloss.Color(choice.Color)).Render(choice.Name) + "\n"
 			}
 		}
-	} else if m.step == 2 {
+		s += "\n" + lipgloss.NewStyle().
+			Foreground(lipgloss.Color("8")).
+			Render(m.choices[m.cursor].Description) + "\n"
+	} else if m.step == stepDB {
 		// DB setup selection
 		dbHeaderStyle := lipgloss.NewStyle().
 			Bold(true).
@@ -145,29 +256,51 @@ func (m model) View() string {
 		}
 	}
 
+	quitHint := "Press q to quit."
+	if m.isTextStep() {
+		quitHint = "Press ctrl+c to quit."
+	}
 	s += "\n" + lipgloss.NewStyle().
 		Foreground(lipgloss.Color("8")).
-		Render("Press q to quit.")
+		Render(quitHint)
 
 	// Center the entire content
 	return lipgloss.NewStyle().Align(lipgloss.Center).Width(30).Render(s)
 }
 
-// GetUserInput runs the Bubble Tea program and returns the selected project name, framework, and setupDB flag
-func GetUserInput() (string, string, bool) {
-	p := tea.NewProgram(initialModel())
+// GetUserInput runs the Bubble Tea program for the values missing from preset
+// and returns the completed answers. If the user quits before answering
+// everything, the returned Answers are not Complete.
+func GetUserInput(preset Answers) (Answers, error) {
+	p := tea.NewProgram(initialModel(preset))
 	finalModel, err := p.Run()
 	if err != nil {
-		fmt.Printf("Error running program: %v\n", err)
-		return "", "", false
+		return preset, fmt.Errorf("error running program: %w", err)
 	}
 
 	// Type assertion for the final model
 	m, ok := finalModel.(model)
 	if !ok {
-		fmt.Println("Could not assert final model")
-		return "", "", false
+		return preset, fmt.Errorf("could not assert final model")
 	}
 
-	return m.projectName, m.framework, m.setupDB
+	return m.answers(), nil
+}
+
+// answers returns the values collected so far
+func (m model) answers() Answers {
+	return Answers{
+		ProjectName:  m.projectName,
+		ModulePath:   m.modulePath,
+		ModulePrefix: m.modulePrefix,
+		Framework:    m.framework,
+		SetupDB:      m.setupDB,
+		DBAnswered:   !m.askDB,
+	}
+}
+
+// Complete reports whether every step was answered, false when the user
+// quit the wizard before the end
+func (a Answers) Complete() bool {
+	return a.ProjectName != "" && a.ModulePath != "" && a.Framework != "" && a.DBAnswered
 }
@@ -0,0 +1,68 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TestQuit checks that quitting the wizard at any step leaves the answers
+// incomplete, including the steps following preset values
+func TestQuit(t *testing.T) {
+	tests := []struct {
+		name   string
+		preset Answers
+		keys   []tea.KeyMsg
+		quit   tea.KeyMsg
+	}{
+		{
+			name: "name",
+			quit: tea.KeyMsg{Type: tea.KeyCtrlC},
+		},
+		{
+			name:   "module with preset framework",
+			preset: Answers{ProjectName: "svc", Framework: "gin"},
+			quit:   tea.KeyMsg{Type: tea.KeyCtrlC},
+		},
+		{
+			name:   "framework",
+			preset: Answers{ProjectName: "svc", ModulePath: "example.com/svc"},
+			quit:   tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")},
+		},
+		{
+			name:   "database",
+			preset: Answers{ProjectName: "svc", ModulePath: "example.com/svc"},
+			keys:   []tea.KeyMsg{{Type: tea.KeyEnter}},
+			quit:   tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := initialModel(tt.preset)
+			for _, key := range tt.keys {
+				m = update(t, m, key)
+			}
+			m = update(t, m, tt.quit)
+			if m.done || m.answers().Complete() {
+				t.Errorf("quitting gave complete answers %+v", m.answers())
+			}
+		})
+	}
+}
+
+// TestComplete checks that answering every step completes the answers
+func TestComplete(t *testing.T) {
+	m := initialModel(Answers{ProjectName: "svc", ModulePath: "example.com/svc"})
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyEnter}) // first framework
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyEnter}) // no database
+	if a := m.answers(); !m.done || !a.Complete() || a.SetupDB {
+		t.Errorf("got %+v, want complete answers without a database", a)
+	}
+}
+
+func update(t *testing.T, m model, key tea.KeyMsg) model {
+	t.Helper()
+	next, _ := m.Update(key)
+	return next.(model)
+}
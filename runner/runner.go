@@ -0,0 +1,127 @@
+// Package runner runs the external commands, mostly the go tool, that
+// scaffolding needs. Code takes a Runner instead of calling os/exec so the
+// commands can be cancelled, logged, recorded or faked in tests.
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Command is a single program invocation
+type Command struct {
+	Dir  string   // Working directory, the current directory when empty
+	Name string   // Program to run, such as "go"
+	Args []string // Arguments, without the program name
+	Env  []string // KEY=VALUE overrides on top of the runner environment
+
+	// Stdout and Stderr stream the output instead of capturing it
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Go returns a go tool command run in dir
+func Go(dir string, args ...string) Command {
+	return Command{Dir: dir, Name: "go", Args: args}
+}
+
+// String returns the command line, such as "go get github.com/gin-gonic/gin"
+func (c Command) String() string {
+	return strings.Join(append([]string{c.Name}, c.Args...), " ")
+}
+
+// Result is the outcome of a command
+type Result struct {
+	Output   []byte        // Combined stdout and stderr, empty when streamed
+	Duration time.Duration // Wall time of the command
+}
+
+// Runner runs commands. Run returns an error when the command cannot be
+// started or exits with a non-zero status; the output is returned either way.
+type Runner interface {
+	Run(ctx context.Context, cmd Command) (Result, error)
+}
+
+// Exec runs commands with os/exec
+type Exec struct {
+	Env []string  // KEY=VALUE overrides applied to every command
+	Log io.Writer // When set, every command and its outcome are logged to it
+}
+
+// Run runs the command, killing it when ctx is done
+func (e *Exec) Run(ctx context.Context, cmd Command) (Result, error) {
+	c := exec.CommandContext(ctx, cmd.Name, cmd.Args...)
+	c.Dir = cmd.Dir
+	if len(e.Env) > 0 || len(cmd.Env) > 0 {
+		// Later entries win, so the overrides replace the inherited values
+		c.Env = append(append(os.Environ(), e.Env...), cmd.Env...)
+	}
+
+	if e.Log != nil {
+		dir := ""
+		if cmd.Dir != "" {
+			dir = " (in " + cmd.Dir + ")"
+		}
+		fmt.Fprintf(e.Log, "$ %s%s\n", cmd, dir)
+		for _, env := range append(append([]string(nil), e.Env...), cmd.Env...) {
+			fmt.Fprintf(e.Log, "    %s\n", env)
+		}
+	}
+
+	var result Result
+	start := time.Now()
+	var err error
+	if cmd.Stdout != nil || cmd.Stderr != nil {
+		c.Stdout, c.Stderr = cmd.Stdout, cmd.Stderr
+		err = c.Run()
+	} else {
+		result.Output, err = c.CombinedOutput()
+	}
+	result.Duration = time.Since(start)
+
+	if e.Log != nil {
+		if err != nil {
+			fmt.Fprintf(e.Log, "    failed after %s: %v\n", result.Duration.Round(time.Millisecond), err)
+		} else {
+			fmt.Fprintf(e.Log, "    done in %s\n", result.Duration.Round(time.Millisecond))
+		}
+	}
+	return result, err
+}
+
+// WithEnv returns a runner adding the KEY=VALUE overrides to every command
+// before passing it to r. Overrides set on a command win.
+func WithEnv(r Runner, env ...string) Runner {
+	return envRunner{r, env}
+}
+
+type envRunner struct {
+	runner Runner
+	env    []string
+}
+
+func (e envRunner) Run(ctx context.Context, cmd Command) (Result, error) {
+	cmd.Env = append(append([]string(nil), e.env...), cmd.Env...)
+	return e.runner.Run(ctx, cmd)
+}
+
+// Recorder records every command before passing it to Runner. With a nil
+// Runner the commands are only recorded and succeed without output.
+type Recorder struct {
+	Runner   Runner
+	Commands []Command
+}
+
+// Run records the command and runs it with the wrapped runner
+func (r *Recorder) Run(ctx context.Context, cmd Command) (Result, error) {
+	r.Commands = append(r.Commands, cmd)
+	if r.Runner == nil {
+		return Result{}, nil
+	}
+	return r.Runner.Run(ctx, cmd)
+}
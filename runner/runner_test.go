@@ -0,0 +1,131 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// printEnv returns an sh command printing the variables GOGINIT_A and GOGINIT_B
+func printEnv(env ...string) Command {
+	return Command{Name: "sh", Args: []string{"-c", "echo $GOGINIT_A $GOGINIT_B"}, Env: env}
+}
+
+func TestExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the commands are sh commands")
+	}
+	t.Setenv("GOGINIT_A", "inherited")
+	t.Setenv("GOGINIT_B", "inherited")
+
+	tests := []struct {
+		name string
+		env  []string // Runner environment
+		cmd  Command
+		want string
+	}{
+		{name: "inherited", cmd: printEnv(), want: "inherited inherited"},
+		{name: "runner", env: []string{"GOGINIT_A=runner"}, cmd: printEnv(), want: "runner inherited"},
+		{name: "command", env: []string{"GOGINIT_A=runner"}, cmd: printEnv("GOGINIT_A=command", "GOGINIT_B=command"), want: "command command"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log bytes.Buffer
+			e := &Exec{Env: tt.env, Log: &log}
+			result, err := e.Run(context.Background(), tt.cmd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(string(result.Output)); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+			if !strings.HasPrefix(log.String(), "$ sh -c echo $GOGINIT_A $GOGINIT_B\n") || !strings.Contains(log.String(), "done in") {
+				t.Errorf("log = %q", log.String())
+			}
+		})
+	}
+
+	// A failing command returns its output with the error
+	result, err := (&Exec{}).Run(context.Background(), Command{Name: "sh", Args: []string{"-c", "echo failed; exit 3"}})
+	if err == nil || string(result.Output) != "failed\n" {
+		t.Errorf("got %q, %v, want the output and an error", result.Output, err)
+	}
+}
+
+func TestWithEnv(t *testing.T) {
+	rec := &Recorder{}
+	r := WithEnv(rec, "GOFLAGS=-mod=mod", "GOPROXY=off")
+
+	cmds := []Command{Go("dir", "version"), {Name: "go", Args: []string{"get"}, Env: []string{"GOPROXY=direct"}}}
+	for _, cmd := range cmds {
+		if _, err := r.Run(context.Background(), cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The command overrides come last, so they win over those of WithEnv
+	want := [][]string{
+		{"GOFLAGS=-mod=mod", "GOPROXY=off"},
+		{"GOFLAGS=-mod=mod", "GOPROXY=off", "GOPROXY=direct"},
+	}
+	if len(rec.Commands) != len(want) {
+		t.Fatalf("recorded %d commands, want %d", len(rec.Commands), len(want))
+	}
+	for i, cmd := range rec.Commands {
+		if !reflect.DeepEqual(cmd.Env, want[i]) {
+			t.Errorf("%s: env = %q, want %q", cmd, cmd.Env, want[i])
+		}
+	}
+	// The environment of the caller's command is left alone
+	if !reflect.DeepEqual(cmds[1].Env, []string{"GOPROXY=direct"}) {
+		t.Errorf("command env changed to %q", cmds[1].Env)
+	}
+}
+
+func TestWithEnvExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the commands are sh commands")
+	}
+	r := WithEnv(&Exec{}, "GOGINIT_A=with", "GOGINIT_B=with")
+	result, err := r.Run(context.Background(), printEnv("GOGINIT_B=command"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(result.Output)); got != "with command" {
+		t.Errorf("output = %q, want %q", got, "with command")
+	}
+}
+
+// stubRunner answers every command with its command line
+type stubRunner struct{}
+
+func (stubRunner) Run(ctx context.Context, cmd Command) (Result, error) {
+	return Result{Output: []byte(cmd.String())}, nil
+}
+
+func TestRecorder(t *testing.T) {
+	// Without a runner, the commands only succeed
+	rec := &Recorder{}
+	result, err := rec.Run(context.Background(), Go("example", "mod", "init", "example"))
+	if err != nil || len(result.Output) != 0 {
+		t.Errorf("got %q, %v, want no output", result.Output, err)
+	}
+
+	// With one, they are run in order
+	rec.Runner = stubRunner{}
+	result, err = rec.Run(context.Background(), Go("example", "mod", "tidy"))
+	if err != nil || string(result.Output) != "go mod tidy" {
+		t.Errorf("got %q, %v, want the output of the runner", result.Output, err)
+	}
+
+	want := []Command{
+		{Dir: "example", Name: "go", Args: []string{"mod", "init", "example"}},
+		{Dir: "example", Name: "go", Args: []string{"mod", "tidy"}},
+	}
+	if !reflect.DeepEqual(rec.Commands, want) {
+		t.Errorf("commands = %+v, want %+v", rec.Commands, want)
+	}
+}
@@ -0,0 +1,311 @@
+package scaffold
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pol-cova/GoGinit/internal/apidoc"
+	"github.com/pol-cova/GoGinit/internal/naming"
+	"github.com/pol-cova/GoGinit/internal/openapi"
+	"github.com/pol-cova/GoGinit/internal/routing"
+)
+
+// OpenAPIDocument is the default path of the OpenAPI document exported from
+// a project
+const OpenAPIDocument = "api/openapi.yaml"
+
+// ExportOptions describes the OpenAPI document to export
+type ExportOptions struct {
+	Info openapi.Info // Title and version of the API, the title defaults to the project name
+
+	// Output is the path of the document, relative to the project directory.
+	// It defaults to OpenAPIDocument.
+	Output string
+
+	// Force overwrites a document that was not exported by goginit
+	Force bool
+}
+
+// ExportOpenAPI writes the OpenAPI 3 document describing the API of the
+// project, see DescribeAPI. The document is rewritten by every export; a
+// document written by hand is only overwritten with Force.
+func ExportOpenAPI(p Project, opts ExportOptions) (Generated, error) {
+	output := opts.Output
+	if output == "" {
+		output = OpenAPIDocument
+	}
+	name := filepath.ToSlash(filepath.Clean(output))
+	if filepath.IsAbs(output) || name == ".." || strings.HasPrefix(name, "../") {
+		return Generated{}, fmt.Errorf("output %s must be inside the project", output)
+	}
+
+	doc, err := DescribeAPI(p, opts.Info)
+	if err != nil {
+		return Generated{}, err
+	}
+	data, err := openapi.Marshal(doc)
+	if err != nil {
+		return Generated{}, err
+	}
+	content := "# Code generated by goginit from the project sources. DO NOT EDIT.\n" + string(data)
+
+	if err := checkGenerated(p, name, opts.Force); err != nil {
+		return Generated{}, err
+	}
+	previous, err := os.ReadFile(filepath.Join(p.Dir, filepath.FromSlash(name)))
+	switch {
+	case err == nil && string(previous) == content:
+		return Generated{}, nil
+	case err == nil:
+		if _, err := writeGenerated(p, map[string]string{name: content}, true); err != nil {
+			return Generated{}, err
+		}
+		return Generated{Modified: []string{name}}, nil
+	}
+	files, err := writeGenerated(p, map[string]string{name: content}, true)
+	return Generated{Files: files}, err
+}
+
+// DescribeAPI derives an OpenAPI 3 document from the sources of the
+// project, without building it. Every route registered, as listed by
+// ListRoutes, is an operation. Its handler is read to find the query
+// parameters, the JSON request body and the responses it writes, following
+// the helpers it calls; the doc comment of the handler gives the summary
+// and description of the operation. The types of the bodies, and all
+// exported types of pkg/models, are schema components.
+//
+// Routes matching every method are described for the methods their handler
+// checks r.Method against, GET when it checks none.
+func DescribeAPI(p Project, info openapi.Info) (*openapi.Document, error) {
+	routes, err := ListRoutes(p)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	err = walkModule(p.Dir, func(path string) error {
+		if !strings.HasSuffix(path, "_test.go") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	ix, err := apidoc.Load(paths)
+	if err != nil {
+		return nil, err
+	}
+	ix.Models(filepath.Join(p.Dir, "pkg", "models"))
+
+	if info.Title == "" {
+		info.Title = filepath.Base(p.Dir)
+	}
+	if info.Version == "" {
+		info.Version = "0.1.0"
+	}
+	doc := &openapi.Document{OpenAPI: "3.0.3", Info: info}
+	ids := map[string]bool{}
+	for _, route := range routes {
+		path, params := openAPIPath(route.Path)
+
+		var h apidoc.Handler
+		fn, found := ix.Handler(filepath.Join(p.Dir, filepath.FromSlash(route.File)), route.Line, route.Handler)
+		if found {
+			h = ix.Describe(fn, p.Framework)
+		}
+		methods := strings.Split(route.Method, ",")
+		if route.Method == routing.AnyMethodName {
+			methods = h.Methods
+			if len(methods) == 0 {
+				methods = []string{http.MethodGet}
+			}
+		}
+
+		i := len(doc.Paths)
+		for j, item := range doc.Paths {
+			if item.Name == path {
+				i = j
+			}
+		}
+		if i == len(doc.Paths) {
+			doc.Paths = append(doc.Paths, openapi.Named[openapi.PathItem]{Name: path})
+		}
+		item := &doc.Paths[i].Value
+		for _, method := range methods {
+			if existing, ok := item.Operations().Get(method); ok && existing != nil {
+				continue
+			}
+			op := describeOperation(p, method, path, params, h)
+			op.OperationID = operationID(ids, method, path, fn, len(methods) > 1)
+			item.SetOperation(method, op)
+		}
+	}
+	doc.Components.Schemas = ix.Schemas()
+	return doc, nil
+}
+
+// describeOperation returns the operation of a handler serving a method on
+// a path
+func describeOperation(p Project, method, path string, params []string, h apidoc.Handler) *openapi.Operation {
+	op := &openapi.Operation{}
+	op.Summary, op.Description = docSummary(h.Doc, h.Name)
+	if tag := pathTag(path); tag != "" {
+		op.Tags = []string{tag}
+	}
+
+	isParam := map[string]bool{}
+	for _, name := range params {
+		isParam[name] = true
+		op.Parameters = append(op.Parameters, openapi.Parameter{
+			Name: name, In: "path", Required: true,
+			Schema: &openapi.Schema{Type: openapi.Types{"string"}},
+		})
+	}
+	for _, name := range h.Query {
+		if !isParam[name] {
+			op.Parameters = append(op.Parameters, openapi.Parameter{
+				Name: name, In: "query",
+				Schema: &openapi.Schema{Type: openapi.Types{"string"}},
+			})
+		}
+	}
+	if h.Body != nil {
+		op.RequestBody = &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.Ordered[*openapi.MediaType]{{Name: apidoc.JSON, Value: &openapi.MediaType{Schema: h.Body}}},
+		}
+	}
+
+	byStatus := map[int]*openapi.Response{}
+	var statuses []int
+	for _, r := range h.Responses {
+		status := r.Status
+		if status == apidoc.DefaultStatus {
+			status = defaultStatus(p.Framework, method, r.Schema != nil)
+		}
+		resp, ok := byStatus[status]
+		if !ok {
+			resp = &openapi.Response{Description: http.StatusText(status)}
+			byStatus[status] = resp
+			statuses = append(statuses, status)
+		}
+		if len(resp.Content) == 0 && r.Schema != nil {
+			resp.Content = openapi.Ordered[*openapi.MediaType]{{Name: r.Type, Value: &openapi.MediaType{Schema: r.Schema}}}
+		}
+	}
+	if len(statuses) == 0 {
+		byStatus[http.StatusOK] = &openapi.Response{Description: http.StatusText(http.StatusOK)}
+		statuses = append(statuses, http.StatusOK)
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		op.Responses = append(op.Responses, openapi.Named[*openapi.Response]{Name: fmt.Sprint(status), Value: byStatus[status]})
+	}
+	return op
+}
+
+// defaultStatus returns the status of the responses written without one,
+// such as the values returned by gofr and fuego handlers. gofr responds 201
+// Created to POST requests, and 204 No Content to DELETE requests or when
+// the handler returns no value.
+func defaultStatus(framework, method string, body bool) int {
+	if framework == "gofr" {
+		switch {
+		case method == http.MethodPost:
+			return http.StatusCreated
+		case method == http.MethodDelete, !body:
+			return http.StatusNoContent
+		}
+	}
+	return http.StatusOK
+}
+
+// openAPIPath returns a route path in the syntax of OpenAPI, with its
+// parameters. Parameters are written :id, *path, {id}, {id:[0-9]+} or
+// {path...} by the frameworks; a bare * wildcard is named path.
+func openAPIPath(path string) (string, []string) {
+	var params []string
+	elems := strings.Split(path, "/")
+	for i, elem := range elems {
+		var name string
+		switch {
+		case elem == "{$}":
+			elems[i] = ""
+			continue
+		case strings.HasPrefix(elem, ":"):
+			name = strings.TrimSuffix(elem[1:], "?")
+		case strings.HasPrefix(elem, "*"):
+			name = elem[1:]
+			if name == "" {
+				name = "path"
+			}
+		case strings.HasPrefix(elem, "{") && strings.HasSuffix(elem, "}"):
+			name, _, _ = strings.Cut(elem[1:len(elem)-1], ":")
+			name = strings.TrimSuffix(name, "...")
+		default:
+			continue
+		}
+		elems[i] = "{" + name + "}"
+		params = append(params, name)
+	}
+	return strings.Join(elems, "/"), params
+}
+
+// operationID names an operation after its handler: userHandler.Get gives
+// getUser. Operations without a known handler are named after their method
+// and path, and the method is added for handlers serving several methods.
+func operationID(ids map[string]bool, method, path string, fn *apidoc.Func, manyMethods bool) string {
+	id := naming.Camel(operationName(method, path))
+	if fn != nil {
+		typ, name, isMethod := strings.Cut(fn.Name, ".")
+		if !isMethod {
+			name, typ = typ, ""
+		}
+		id = naming.Camel(name + " " + strings.TrimSuffix(typ, "Handler"))
+		if manyMethods {
+			id = naming.Camel(strings.ToLower(method) + " " + id)
+		}
+	}
+	unique := id
+	for n := 2; ids[unique]; n++ {
+		unique = fmt.Sprint(id, n)
+	}
+	ids[unique] = true
+	return unique
+}
+
+// docSummary splits the doc comment of a handler into the summary and the
+// description of its operation. The first paragraph is the summary, without
+// the name of the handler it starts with; "X handles GET /path", the
+// comment of generated handlers, is skipped.
+func docSummary(doc, name string) (summary, description string) {
+	paragraphs := strings.Split(doc, "\n\n")
+	if first := strings.Fields(paragraphs[0]); len(first) > 2 && first[1] == "handles" && validMethod(first[2]) {
+		paragraphs = paragraphs[1:]
+	}
+	if len(paragraphs) == 0 || paragraphs[0] == "" {
+		return "", ""
+	}
+
+	summary = strings.Join(strings.Fields(paragraphs[0]), " ")
+	if rest, ok := strings.CutPrefix(summary, name+" "); ok && name != "" && rest != "" {
+		summary = strings.ToUpper(rest[:1]) + rest[1:]
+	}
+	return strings.TrimSuffix(summary, "."), strings.Join(paragraphs[1:], "\n\n")
+}
+
+// pathTag returns the tag grouping the operations of a path: its first
+// segment, after an api prefix and a version such as v1
+func pathTag(path string) string {
+	for _, elem := range strings.Split(path, "/") {
+		if elem == "" || elem == "api" || strings.HasPrefix(elem, "{") || len(elem) > 1 && elem[0] == 'v' && strings.Trim(elem[1:], "0123456789") == "" {
+			continue
+		}
+		return elem
+	}
+	return ""
+}
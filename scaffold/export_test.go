@@ -0,0 +1,200 @@
+package scaffold
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pol-cova/GoGinit/internal/openapi"
+)
+
+// TestDescribeAPI checks what is derived from handlers written by hand:
+// methods checked on routes matching any method, query parameters, bodies
+// written by helpers, doc comments and the schemas of named types
+func TestDescribeAPI(t *testing.T) {
+	p := Project{Dir: t.TempDir(), Module: "example.com/example", Framework: "default"}
+	files := map[string]string{
+		"main.go": `package main
+
+import (
+	"net/http"
+
+	"example.com/example/internal/handlers"
+)
+
+func main() {
+	mux := http.NewServeMux()
+	h := handlers.New()
+	mux.HandleFunc("/orders", h.Orders)
+	mux.HandleFunc("GET /orders/{id}", h.Order)
+	http.ListenAndServe(":3000", mux)
+}
+`,
+		"internal/handlers/orders.go": `package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"example.com/example/pkg/models"
+)
+
+type Handler struct{}
+
+func New() *Handler { return &Handler{} }
+
+// Orders lists the orders of a customer.
+//
+// Orders are sorted by date.
+func (h *Handler) Orders(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		var order models.Order
+		if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusCreated, order)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	customer := r.URL.Query().Get("customer")
+	writeJSON(w, 200, []models.Order{{Customer: customer}})
+}
+
+// Order handles GET /orders/{id}
+func (h *Handler) Order(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusNotFound, map[string]string{"error": "no order " + r.PathValue("id")})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+`,
+		"pkg/models/order.go": `package models
+
+// Order is an order placed by a customer
+type Order struct {
+	ID       int64   ` + "`json:\"id\"`" + `
+	Customer string  ` + "`json:\"customer\"`" + `
+	Status   Status  ` + "`json:\"status\"`" + `
+	Note     *string ` + "`json:\"note,omitempty\"`" + `
+	secret   string
+}
+
+// Status is the state of an order
+type Status string
+
+const (
+	Pending Status = "pending"
+	Shipped Status = "shipped"
+)
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(p.Dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	doc, err := DescribeAPI(p, openapi.Info{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Info.Title != filepath.Base(p.Dir) || doc.Info.Version == "" {
+		t.Errorf("info %+v, want the project name and a version", doc.Info)
+	}
+
+	orders, _ := doc.Paths.Get("/orders")
+	if orders.Get == nil || orders.Post == nil || orders.Put != nil {
+		t.Fatalf("/orders has operations %v, want GET and POST", names(orders.Operations()))
+	}
+	list := orders.Get
+	if list.OperationID != "getOrders" || list.Summary != "Lists the orders of a customer" || list.Description != "Orders are sorted by date." {
+		t.Errorf("GET /orders is %q: %q, %q", list.OperationID, list.Summary, list.Description)
+	}
+	if len(list.Parameters) != 1 || list.Parameters[0].Name != "customer" || list.Parameters[0].In != "query" {
+		t.Errorf("GET /orders has parameters %+v, want the customer query parameter", list.Parameters)
+	}
+	if got, want := names(list.Responses), []string{"200", "201", "400", "405"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GET /orders responds %v, want %v", got, want)
+	}
+	if ok, _ := list.Responses.Get("200"); ok.Content[0].Value.Schema.Items.Ref != "#/components/schemas/Order" {
+		t.Errorf("GET /orders 200 has schema %+v, want an array of orders", ok.Content[0].Value.Schema)
+	}
+	if body := orders.Post.RequestBody; body == nil || body.Content[0].Value.Schema.Ref != "#/components/schemas/Order" {
+		t.Errorf("POST /orders has body %+v, want an order", body)
+	}
+
+	order, _ := doc.Paths.Get("/orders/{id}")
+	if order.Get == nil || order.Get.Summary != "" || len(order.Get.Parameters) != 1 || !order.Get.Parameters[0].Required {
+		t.Fatalf("GET /orders/{id} is %+v", order.Get)
+	}
+	notFound, _ := order.Get.Responses.Get("404")
+	if notFound == nil || !reflect.DeepEqual(names(notFound.Content[0].Value.Schema.Properties), []string{"error"}) {
+		t.Errorf("GET /orders/{id} 404 is %+v, want an object with an error", notFound)
+	}
+
+	if got, want := names(doc.Components.Schemas), []string{"Order", "Status"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("schemas %v, want %v", got, want)
+	}
+	schema, _ := doc.Components.Schemas.Get("Order")
+	if got, want := names(schema.Properties), []string{"id", "customer", "status", "note"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Order has properties %v, want %v", got, want)
+	}
+	if got, want := schema.Required, []string{"id", "customer", "status"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Order requires %v, want %v", got, want)
+	}
+	if schema.Description != "Order is an order placed by a customer" {
+		t.Errorf("Order has description %q", schema.Description)
+	}
+	status, _ := doc.Components.Schemas.Get("Status")
+	if !reflect.DeepEqual(status.Enum, []any{"pending", "shipped"}) {
+		t.Errorf("Status has enum %v", status.Enum)
+	}
+}
+
+// TestExportOpenAPIHandWritten checks that a document written by hand is
+// only overwritten with Force
+func TestExportOpenAPIHandWritten(t *testing.T) {
+	p := Project{Dir: t.TempDir(), Module: "example.com/example", Framework: "chi"}
+	path := filepath.Join(p.Dir, filepath.FromSlash(OpenAPIDocument))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("openapi: 3.0.3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ExportOpenAPI(p, ExportOptions{}); !errors.Is(err, ErrExists) {
+		t.Fatalf("got %v, want ErrExists", err)
+	}
+	generated, err := ExportOpenAPI(p, ExportOptions{Force: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(generated.Modified, []string{OpenAPIDocument}) {
+		t.Errorf("got %+v, want the document updated", generated)
+	}
+	if _, err := ExportOpenAPI(p, ExportOptions{}); err != nil {
+		t.Errorf("exporting over an exported document: %v", err)
+	}
+}
+
+func names[T any](o openapi.Ordered[T]) []string {
+	var names []string
+	for _, entry := range o {
+		names = append(names, entry.Name)
+	}
+	return names
+}
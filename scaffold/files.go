@@ -0,0 +1,123 @@
+package scaffold
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/pol-cova/GoGinit/config"
+	"github.com/pol-cova/GoGinit/internal/db"
+	"github.com/pol-cova/GoGinit/templates"
+)
+
+// projectDirs returns the skeleton directories relative to the project root.
+// A user template only creates the directories holding its files.
+func projectDirs(spec config.ProjectSpec) []string {
+	if spec.Template != "" {
+		return nil
+	}
+	return []string{
+		filepath.Join("cmd", spec.BinaryName()),
+		filepath.Join("internal", "handlers"),
+		filepath.Join("internal", "middleware"),
+		filepath.Join("internal", "routes"),
+		filepath.Join("pkg", "models"),
+		filepath.Join("pkg", "db"),
+	}
+}
+
+// projectFiles returns the skeleton files keyed by their path relative to
+// the project root, rendered from the user template when the spec names one
+func projectFiles(spec config.ProjectSpec) (map[string]string, error) {
+	frameworkConfig, err := spec.FrameworkConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid framework selected: %w", err)
+	}
+
+	// User templates replace the built-in layout entirely
+	trees := []fs.FS{templates.Common, frameworkConfig.Templates}
+	if spec.Template != "" {
+		dir, err := config.ResolveTemplateDir(spec.Template)
+		if err != nil {
+			return nil, err
+		}
+		trees = []fs.FS{os.DirFS(dir)}
+	}
+
+	data := templateData(spec, frameworkConfig)
+	files := map[string]string{}
+	for _, tree := range trees {
+		rendered, err := templates.Render(tree, data)
+		if err != nil {
+			return nil, err
+		}
+		for path, content := range rendered {
+			files[filepath.FromSlash(path)] = content
+		}
+	}
+
+	// Conditionally add the db.go file, generated by the database setup
+	if spec.SetupDB() && spec.Template == "" {
+		files[filepath.Join("pkg", "db", "db.go")] = "// db package\npackage db"
+	}
+	return files, nil
+}
+
+// templateData returns the variables available to templates and hooks
+func templateData(spec config.ProjectSpec, frameworkConfig config.FrameworkConfig) templates.Data {
+	return templates.Data{
+		ProjectName:      spec.Name,
+		ModulePath:       spec.ModulePath(),
+		Binary:           spec.BinaryName(),
+		Framework:        spec.Framework,
+		FrameworkImport:  frameworkConfig.ImportPath,
+		FrameworkVersion: frameworkConfig.Version,
+		Database:         spec.Database,
+		GoVersion:        spec.GoVersion,
+	}
+}
+
+// finalFiles returns every file of the generated project except go.mod and
+// go.sum: the skeleton rendered for rendered, see templateSpec, the database
+// files and the recorded spec
+func finalFiles(spec, rendered config.ProjectSpec) (map[string]string, error) {
+	files, err := projectFiles(rendered)
+	if err != nil {
+		return nil, err
+	}
+	if spec.SetupDB() {
+		dbFiles, err := db.Files(spec.Name)
+		if err != nil {
+			return nil, err
+		}
+		for path, content := range dbFiles {
+			files[path] = content
+		}
+	}
+	specData, err := config.EncodeSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	files[config.SpecFileName] = string(specData)
+	return files, nil
+}
+
+// writeProjectFiles creates the skeleton directories and files in root
+func writeProjectFiles(root string, spec config.ProjectSpec, files map[string]string) error {
+	for _, dir := range projectDirs(spec) {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			return fmt.Errorf("error creating directory: %w", err)
+		}
+	}
+
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Join(root, filepath.Dir(path)), 0755); err != nil {
+			return fmt.Errorf("error creating directory: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(root, path), []byte(content), 0644); err != nil {
+			return fmt.Errorf("error creating file: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,384 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/pol-cova/GoGinit/config"
+	"github.com/pol-cova/GoGinit/runner"
+)
+
+// fakeGo answers go version and go mod edit -json, and succeeds without
+// output for every other command
+type fakeGo struct{}
+
+func (fakeGo) Run(ctx context.Context, cmd runner.Command) (runner.Result, error) {
+	switch cmd.String() {
+	case "go version":
+		return runner.Result{Output: []byte("go version go1.22.0 linux/amd64\n")}, nil
+	case "go mod edit -json":
+		return runner.Result{Output: []byte(`{"Go": "1.23"}`)}, nil
+	}
+	return runner.Result{}, nil
+}
+
+// TestGenerateGoVersion checks the go directive commands and the refusal of
+// frameworks needing a newer Go than targeted
+func TestGenerateGoVersion(t *testing.T) {
+	spec := config.ProjectSpec{
+		Name:      "example",
+		Framework: "chi",
+		GoVersion: "1.23",
+		Toolchain: "go1.23.4",
+	}
+	result, err := Generate(context.Background(), Options{Spec: spec, Dir: t.TempDir(), Runner: fakeGo{}, SkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var edits []string
+	for _, cmd := range result.Commands {
+		if len(cmd.Args) > 1 && cmd.Args[1] == "edit" {
+			edits = append(edits, cmd.String())
+		}
+	}
+	want := []string{"go mod edit -go=1.23 -toolchain=go1.23.4", "go mod edit -json"}
+	if !reflect.DeepEqual(edits, want) {
+		t.Errorf("go mod edit commands = %q, want %q", edits, want)
+	}
+
+	spec.Name, spec.GoVersion = "older", "1.22"
+	if _, err := Generate(context.Background(), Options{Spec: spec, Dir: t.TempDir(), Runner: fakeGo{}}); err == nil {
+		t.Error("chi with Go 1.22 was not refused")
+	}
+}
+
+// TestGenerateCommands runs Generate with a fake toolchain and checks the
+// commands it runs and the files it reports
+func TestGenerateCommands(t *testing.T) {
+	spec := config.ProjectSpec{
+		Name:      "example",
+		Module:    "example.com/example",
+		Framework: "gin",
+		Database:  config.DatabaseSQLite,
+	}
+	frameworkConfig, err := spec.FrameworkConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	result, err := Generate(context.Background(), Options{Spec: spec, Dir: dir, Runner: fakeGo{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := filepath.Join(dir, spec.Name)
+	if result.Dir != root {
+		t.Errorf("Dir = %s, want %s", result.Dir, root)
+	}
+	var got []string
+	for _, cmd := range result.Commands {
+		got = append(got, cmd.String())
+	}
+	want := []string{
+		"go version",
+		"go mod init example.com/example",
+		"go version",
+		"go get " + frameworkConfig.Dependency(),
+		"go get " + config.SQLiteDependency(),
+		"go build ./...",
+		"go vet ./...",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("commands = %q, want %q", got, want)
+	}
+
+	for _, file := range result.Files {
+		if _, err := os.Stat(filepath.Join(root, file)); err != nil {
+			t.Errorf("reported file %s: %v", file, err)
+		}
+	}
+	if result.Spec.FrameworkVersion != frameworkConfig.Version {
+		t.Errorf("recorded framework version = %q, want %q", result.Spec.FrameworkVersion, frameworkConfig.Version)
+	}
+}
+
+// offlineGo is fakeGo with a module cache
+type offlineGo struct {
+	fakeGo
+	modCache string
+}
+
+func (g offlineGo) Run(ctx context.Context, cmd runner.Command) (runner.Result, error) {
+	if cmd.String() == "go env GOMODCACHE" {
+		return runner.Result{Output: []byte(g.modCache + "\n")}, nil
+	}
+	return g.fakeGo.Run(ctx, cmd)
+}
+
+// TestGenerateOffline checks that offline mode resolves the pinned framework
+// and SQLite driver from the download cache of the module cache, and lists
+// those missing from it before creating anything
+func TestGenerateOffline(t *testing.T) {
+	spec := config.ProjectSpec{Name: "example", Framework: "gin", Database: config.DatabaseSQLite}
+	frameworkConfig, err := spec.FrameworkConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	modCache := t.TempDir()
+	download := filepath.Join(modCache, "cache", "download")
+	for _, zip := range []string{
+		frameworkConfig.ImportPath + "/@v/" + frameworkConfig.Version + ".zip",
+		config.SQLiteImportPath + "/@v/" + config.SQLiteVersion + ".zip",
+	} {
+		path := filepath.Join(download, filepath.FromSlash(zip))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := Options{Spec: spec, Dir: t.TempDir(), Runner: offlineGo{modCache: modCache}, Offline: true, SkipVerify: true}
+
+	result, err := Generate(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := "GOPROXY=file://" + filepath.ToSlash(download)
+	var gets []string
+	for _, cmd := range result.Commands {
+		if len(cmd.Args) == 0 || cmd.Args[0] != "get" {
+			continue
+		}
+		gets = append(gets, cmd.String())
+		if !reflect.DeepEqual(cmd.Env, []string{proxy, "GOSUMDB=off"}) {
+			t.Errorf("%s ran with %q, want %s", cmd, cmd.Env, proxy)
+		}
+	}
+	want := []string{"go get " + frameworkConfig.Dependency(), "go get " + config.SQLiteDependency()}
+	if !reflect.DeepEqual(gets, want) {
+		t.Errorf("go get commands = %q, want %q", gets, want)
+	}
+
+	if err := os.RemoveAll(filepath.Join(download, filepath.FromSlash(config.SQLiteImportPath))); err != nil {
+		t.Fatal(err)
+	}
+	opts.Spec.Name = "missing"
+	_, err = Generate(context.Background(), opts)
+	var missing *config.MissingModulesError
+	if !errors.As(err, &missing) || !reflect.DeepEqual(missing.Modules, []string{config.SQLiteDependency()}) {
+		t.Fatalf("got %v, want the SQLite driver missing", err)
+	}
+	if _, err := os.Stat(filepath.Join(opts.Dir, "missing")); !os.IsNotExist(err) {
+		t.Errorf("project created despite missing modules: %v", err)
+	}
+}
+
+// interruptingGo is fakeGo cancelling the generation when it runs a command
+type interruptingGo struct {
+	fakeGo
+	cancel  context.CancelFunc
+	command string
+}
+
+func (g interruptingGo) Run(ctx context.Context, cmd runner.Command) (runner.Result, error) {
+	if cmd.String() == g.command {
+		g.cancel()
+		return runner.Result{}, ctx.Err()
+	}
+	return g.fakeGo.Run(ctx, cmd)
+}
+
+// TestGenerateInterrupted checks that an interruption before the project is
+// written leaves nothing behind, and that one during the verification keeps
+// the project and says so
+func TestGenerateInterrupted(t *testing.T) {
+	spec := config.ProjectSpec{Name: "example", Framework: "gin"}
+	frameworkConfig, err := spec.FrameworkConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	r := interruptingGo{cancel: cancel, command: "go get " + frameworkConfig.Dependency()}
+	if _, err := Generate(ctx, Options{Spec: spec, Dir: dir, Runner: r}); !errors.Is(err, ErrInterrupted) {
+		t.Errorf("interrupted go get: got %v, want ErrInterrupted", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, spec.Name)); !os.IsNotExist(err) {
+		t.Errorf("interrupted go get left the project: %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	r = interruptingGo{cancel: cancel, command: "go build ./..."}
+	_, err = Generate(ctx, Options{Spec: spec, Dir: dir, Runner: r})
+	var interrupted *InterruptedError
+	if !errors.As(err, &interrupted) || interrupted.Dir != filepath.Join(dir, spec.Name) {
+		t.Fatalf("interrupted verification: got %v, want *InterruptedError", err)
+	}
+	if _, err := os.Stat(interrupted.Dir); err != nil {
+		t.Errorf("interrupted verification did not keep the project: %v", err)
+	}
+}
+
+// TestGenerateFailureCleanup checks that a failing step removes the staging
+// directory and leaves no project behind
+func TestGenerateFailureCleanup(t *testing.T) {
+	spec := config.ProjectSpec{Name: "example", Framework: "gin"}
+	frameworkConfig, err := spec.FrameworkConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		command string
+		step    Step // Failed step, empty for the pre hook
+	}{
+		{command: "go mod init example", step: StepModule},
+		{command: "go get " + frameworkConfig.Dependency(), step: StepDependencies},
+		{command: "sh -c echo pre"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if tt.step == "" && runtime.GOOS == "windows" {
+				t.Skip("the hook is an sh command")
+			}
+			dir := t.TempDir()
+			rec := &runner.Recorder{Runner: failingGo{command: tt.command}}
+			hooks := config.Hooks{Pre: []config.Hook{{Run: "echo pre"}}}
+			_, err := Generate(context.Background(), Options{Spec: spec, Dir: dir, Runner: rec, Hooks: hooks})
+
+			var stepErr *StepError
+			var hookErr *HookError
+			if tt.step != "" && (!errors.As(err, &stepErr) || stepErr.Step != tt.step) {
+				t.Fatalf("got %v, want the %s step error", err, tt.step)
+			}
+			if tt.step == "" && !errors.As(err, &hookErr) {
+				t.Fatalf("got %v, want the pre hook error", err)
+			}
+			if n := len(rec.Commands); n == 0 || rec.Commands[n-1].String() != tt.command {
+				t.Errorf("commands = %v, want them to stop at %s", rec.Commands, tt.command)
+			}
+
+			// Neither the project nor its staging directory is left in dir
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range entries {
+				t.Errorf("%s left %s behind", tt.command, entry.Name())
+			}
+		})
+	}
+}
+
+// olderGo is fakeGo with Go 1.21 installed
+type olderGo struct{ fakeGo }
+
+func (g olderGo) Run(ctx context.Context, cmd runner.Command) (runner.Result, error) {
+	if cmd.String() == "go version" {
+		return runner.Result{Output: []byte("go version go1.21.5 linux/amd64\n")}, nil
+	}
+	return g.fakeGo.Run(ctx, cmd)
+}
+
+// TestPlanProjectGoVersion checks that without a target the plan renders the
+// templates for the installed toolchain, as Generate does
+func TestPlanProjectGoVersion(t *testing.T) {
+	opts := Options{Spec: config.ProjectSpec{Name: "example", Framework: "default"}, Dir: t.TempDir(), Runner: olderGo{}, SkipVerify: true}
+	plan, err := PlanProject(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Generate(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"internal/routes/routes.go", config.SpecFileName} {
+		written, err := os.ReadFile(filepath.Join(result.Dir, filepath.FromSlash(path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plan.Files[path] != string(written) {
+			t.Errorf("planned %s:\n%s\nwritten:\n%s", path, plan.Files[path], written)
+		}
+	}
+}
+
+// TestPlanProjectOpenAPI checks that the plan lists the files generated
+// from an OpenAPI document, with the content Generate writes
+func TestPlanProjectOpenAPI(t *testing.T) {
+	spec := config.ProjectSpec{Name: "example", Framework: "chi", GoVersion: "1.23"}
+	opts := Options{Spec: spec, Dir: t.TempDir(), Runner: fakeGo{}, SkipVerify: true, OpenAPI: filepath.Join("testdata", "openapi.yaml")}
+	plan, err := PlanProject(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(opts.Dir); len(entries) != 0 {
+		t.Errorf("the plan wrote %d entries", len(entries))
+	}
+	result, err := Generate(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plan.Files) != len(result.Files) {
+		t.Errorf("planned %d files, generated %q", len(plan.Files), result.Files)
+	}
+	for _, path := range result.Files {
+		written, err := os.ReadFile(filepath.Join(result.Dir, filepath.FromSlash(path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plan.Files[path] != string(written) {
+			t.Errorf("planned %s:\n%s\nwritten:\n%s", path, plan.Files[path], written)
+		}
+	}
+}
+
+// TestGenerateUserTemplate checks that a user template replaces the built-in
+// layout, with only the contents of its .tmpl files rendered
+func TestGenerateUserTemplate(t *testing.T) {
+	templateDir := t.TempDir()
+	files := map[string]string{
+		"cmd/{{.Binary}}/main.go.tmpl": "package main\n\n// {{.ModulePath}} on {{.Framework}}\nfunc main() {}\n",
+		".github/workflows/ci.yml":     "token: ${{ secrets.TOKEN }}\n",
+	}
+	for path, content := range files {
+		path = filepath.Join(templateDir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spec := config.ProjectSpec{Name: "example", Framework: "chi", Template: templateDir}
+	result, err := Generate(context.Background(), Options{Spec: spec, Dir: t.TempDir(), Runner: fakeGo{}, SkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"cmd/example/main.go":      "package main\n\n// example on chi\nfunc main() {}\n",
+		".github/workflows/ci.yml": "token: ${{ secrets.TOKEN }}\n",
+	}
+	for path, content := range want {
+		written, err := os.ReadFile(filepath.Join(result.Dir, filepath.FromSlash(path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(written) != content {
+			t.Errorf("%s = %q, want %q", path, written, content)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(result.Dir, "internal", "routes")); err == nil {
+		t.Error("the built-in layout was generated along the user template")
+	}
+}
@@ -0,0 +1,156 @@
+package scaffold
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pol-cova/GoGinit/templates"
+)
+
+// ErrExists is returned by the generators when a file they would write
+// already exists and overwriting was not requested
+var ErrExists = errors.New("file already exists")
+
+// Generated describes the code added to a project by a generator
+type Generated struct {
+	Files      []string // Written files, slash-separated and relative to the project directory
+	Modified   []string // Existing files changed, such as the routes
+	Routes     []string // Statements to add to routes.Register to serve the new code
+	Middleware []string // Statements to add to middleware.Register to attach the new middleware
+	Kept       []string // Existing declarations left untouched, such as handlers already written
+}
+
+// renderGenerator renders a variant of the template tree of a generator, see
+// templates.Generator, and formats the Go files
+func renderGenerator(kind, variant string, data any) (map[string]string, error) {
+	fsys, ok := templates.Generator(kind, variant)
+	if !ok {
+		return nil, fmt.Errorf("the %s generator does not support %s", kind, variant)
+	}
+	files, err := templates.Render(fsys, data)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, content := range files {
+		if !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		src, err := format.Source([]byte(content))
+		if err != nil {
+			return nil, fmt.Errorf("generated file %s is not valid Go, this is a template bug: %w", name, err)
+		}
+		files[name] = string(src)
+	}
+	return files, nil
+}
+
+// writeGenerated writes the rendered files into the project, refusing to
+// overwrite existing files unless force is set. Nothing is written when a
+// file exists.
+func writeGenerated(p Project, files map[string]string, force bool) ([]string, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if !force {
+		for _, name := range names {
+			if _, err := os.Stat(filepath.Join(p.Dir, filepath.FromSlash(name))); err == nil {
+				return nil, fmt.Errorf("%w: %s", ErrExists, name)
+			}
+		}
+	}
+
+	for _, name := range names {
+		target := filepath.Join(p.Dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return nil, fmt.Errorf("error creating directory for %s: %w", name, err)
+		}
+		if err := os.WriteFile(target, []byte(files[name]), 0644); err != nil {
+			return nil, fmt.Errorf("error writing %s: %w", name, err)
+		}
+	}
+	return names, nil
+}
+
+// checkDeclarations fails when a package the files are written to already
+// declares one of their top-level names in another file
+func checkDeclarations(p Project, files map[string]string) error {
+	fset := token.NewFileSet()
+	declared := map[string]string{} // name in package directory -> file
+	for name, content := range files {
+		if !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, content, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		for _, ident := range topLevelNames(f) {
+			declared[path.Join(path.Dir(name), ident)] = name
+		}
+	}
+
+	dirs := map[string]bool{}
+	for key := range declared {
+		dirs[path.Dir(key)] = true
+	}
+	for dir := range dirs {
+		matches, err := filepath.Glob(filepath.Join(p.Dir, filepath.FromSlash(dir), "*.go"))
+		if err != nil {
+			return err
+		}
+		for _, match := range matches {
+			rel := path.Join(dir, filepath.Base(match))
+			if _, ok := files[rel]; ok {
+				continue
+			}
+			f, err := parser.ParseFile(fset, match, nil, parser.SkipObjectResolution)
+			if err != nil {
+				return err
+			}
+			for _, ident := range topLevelNames(f) {
+				if _, ok := declared[path.Join(dir, ident)]; ok {
+					return fmt.Errorf("%s is already declared in %s", ident, rel)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// topLevelNames returns the names declared at the top level of a file
+func topLevelNames(f *ast.File) []string {
+	var names []string
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil {
+				names = append(names, decl.Name.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					names = append(names, spec.Name.Name)
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						names = append(names, name.Name)
+					}
+				}
+			}
+		}
+	}
+	return names
+}
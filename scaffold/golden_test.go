@@ -0,0 +1,361 @@
+package scaffold
+
+import (
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pol-cova/GoGinit/config"
+	"github.com/pol-cova/GoGinit/internal/openapi"
+	"github.com/pol-cova/GoGinit/templates"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// TestGoldenProjects generates a project for every framework and database
+// combination, plus older Go targets, and compares its file tree with testdata/golden/<name>.golden.
+// Run go test ./scaffold -update to accept template changes.
+func TestGoldenProjects(t *testing.T) {
+	var specs []config.ProjectSpec
+	for _, framework := range config.Frameworks() {
+		for _, database := range []string{config.DatabaseNone, config.DatabaseSQLite} {
+			specs = append(specs, config.ProjectSpec{Framework: framework.Name, FrameworkVersion: framework.Version, Database: database})
+		}
+	}
+	// Templates adapting to older Go versions
+	specs = append(specs, config.ProjectSpec{Framework: "default", GoVersion: "1.21"})
+
+	for _, spec := range specs {
+		spec.Name, spec.Module = "example", "example.com/example"
+		name := spec.Framework
+		if spec.SetupDB() {
+			name += "-" + spec.Database
+		}
+		if spec.GoVersion != "" {
+			name += "-go" + spec.GoVersion
+		}
+
+		t.Run(name, func(t *testing.T) {
+			files, err := finalFiles(spec, spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			root := filepath.Join(t.TempDir(), spec.Name)
+			if err := writeProjectFiles(root, spec, files); err != nil {
+				t.Fatal(err)
+			}
+
+			checkGolden(t, name, archiveTree(t, root))
+		})
+	}
+}
+
+// TestGoldenHandlers generates a handler with path parameters for every
+// framework and compares it with testdata/golden/handler-<framework>.golden
+func TestGoldenHandlers(t *testing.T) {
+	for _, framework := range config.FrameworkNames() {
+		t.Run(framework, func(t *testing.T) {
+			p := Project{Dir: filepath.Join(t.TempDir(), "example"), Module: "example.com/example", Framework: framework}
+			if _, err := GenerateHandler(p, HandlerOptions{Name: "get order", Path: "/users/:user/orders/{id}"}); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, "handler-"+framework, archiveTree(t, p.Dir))
+		})
+	}
+}
+
+// TestGoldenModels generates a model with every field type and modifier,
+// with and without a database, and compares it with
+// testdata/golden/model[-<database>].golden
+func TestGoldenModels(t *testing.T) {
+	var fields []string
+	for _, typ := range FieldTypes() {
+		fields = append(fields, typ+"_field:"+typ)
+	}
+	fields = append(fields, "email:string:unique", "nickname:string:null", "deleted_at:time:null")
+
+	for _, database := range []string{config.DatabaseNone, config.DatabaseSQLite} {
+		name := "model"
+		if database != config.DatabaseNone {
+			name += "-" + database
+		}
+		t.Run(name, func(t *testing.T) {
+			p := Project{Dir: t.TempDir(), Module: "example.com/example", Framework: "default", Database: database}
+			if _, err := GenerateModel(p, ModelOptions{Name: "user account", Fields: fields}); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, name, archiveTree(t, p.Dir))
+		})
+	}
+}
+
+// TestGoldenResources generates a resource for every framework in a project
+// with a database and a Register function, and compares it with
+// testdata/golden/resource-<framework>.golden
+func TestGoldenResources(t *testing.T) {
+	for _, framework := range config.FrameworkNames() {
+		t.Run(framework, func(t *testing.T) {
+			p := Project{Dir: filepath.Join(t.TempDir(), "example"), Module: "example.com/example", Framework: framework, Database: config.DatabaseSQLite}
+			writeRoutes(t, p)
+
+			generated, err := GenerateResource(p, ModelOptions{Name: "blog post", Fields: []string{"title:string:unique", "views:int", "rating:float:null"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(generated.Modified) != 1 || len(generated.Routes) != 0 {
+				t.Errorf("routes not registered: %+v", generated)
+			}
+			checkGolden(t, "resource-"+framework, archiveTree(t, p.Dir))
+		})
+	}
+}
+
+// TestGoldenOpenAPI generates the models, handler stubs and routes of
+// testdata/openapi.yaml for every framework and compares them with
+// testdata/golden/openapi-<framework>.golden, then checks that generating
+// again keeps every handler
+func TestGoldenOpenAPI(t *testing.T) {
+	for _, framework := range config.FrameworkNames() {
+		t.Run(framework, func(t *testing.T) {
+			p := Project{Dir: filepath.Join(t.TempDir(), "example"), Module: "example.com/example", Framework: framework}
+			writeRoutes(t, p)
+			opts := OpenAPIOptions{Spec: filepath.Join("testdata", "openapi.yaml")}
+
+			generated, err := GenerateOpenAPI(p, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(generated.Modified) != 1 || len(generated.Routes) != 0 {
+				t.Errorf("routes not registered: %+v", generated)
+			}
+			checkGolden(t, "openapi-"+framework, archiveTree(t, p.Dir))
+
+			again, err := GenerateOpenAPI(p, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(again.Files) != 0 || len(again.Modified) != 0 || len(again.Kept) != 5 {
+				t.Errorf("second generation changed the project: %+v", again)
+			}
+		})
+	}
+}
+
+// TestGoldenExport exports the OpenAPI document of a project holding a
+// generated resource for every framework and compares it with
+// testdata/golden/export-<framework>.golden
+func TestGoldenExport(t *testing.T) {
+	for _, framework := range config.FrameworkNames() {
+		t.Run(framework, func(t *testing.T) {
+			p := Project{Dir: filepath.Join(t.TempDir(), "example"), Module: "example.com/example", Framework: framework, Database: config.DatabaseSQLite}
+			writeRoutes(t, p)
+			if _, err := GenerateResource(p, ModelOptions{Name: "blog post", Fields: []string{"title:string:unique", "views:int", "rating:float:null"}}); err != nil {
+				t.Fatal(err)
+			}
+
+			generated, err := ExportOpenAPI(p, ExportOptions{Info: openapi.Info{Title: "Example", Version: "1.0.0"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(generated.Files) != 1 || generated.Files[0] != OpenAPIDocument {
+				t.Errorf("document not written: %+v", generated)
+			}
+			content, err := os.ReadFile(filepath.Join(p.Dir, OpenAPIDocument))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, "export-"+framework, string(content))
+
+			again, err := ExportOpenAPI(p, ExportOptions{Info: openapi.Info{Title: "Example", Version: "1.0.0"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(again.Files) != 0 || len(again.Modified) != 0 {
+				t.Errorf("second export changed the document: %+v", again)
+			}
+		})
+	}
+}
+
+// TestGoldenMiddleware generates every middleware of the catalog and a
+// custom one for every framework, plus an older Go target, in a project
+// with the framework's middleware.Register, and compares them with
+// testdata/golden/middleware-<name>.golden, then checks that generating
+// again leaves Register alone
+func TestGoldenMiddleware(t *testing.T) {
+	projects := map[string]Project{}
+	for _, framework := range config.FrameworkNames() {
+		projects[framework] = Project{Framework: framework}
+	}
+	// Templates adapting to older Go versions
+	projects["gin-go1.20"] = Project{Framework: "gin", GoVersion: "1.20"}
+
+	for name, p := range projects {
+		t.Run(name, func(t *testing.T) {
+			p.Dir, p.Module = filepath.Join(t.TempDir(), "example"), "example.com/example"
+			writeMiddleware(t, p)
+
+			for _, kind := range MiddlewareKinds() {
+				generated, err := GenerateMiddleware(p, MiddlewareOptions{Kind: kind.Name})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(generated.Modified) != 1 || len(generated.Middleware) != 0 {
+					t.Errorf("%s not attached: %+v", kind.Name, generated)
+				}
+			}
+			if _, err := GenerateMiddleware(p, MiddlewareOptions{Custom: "rate limit"}); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, "middleware-"+name, archiveTree(t, p.Dir))
+
+			for _, kind := range MiddlewareKinds() {
+				again, err := GenerateMiddleware(p, MiddlewareOptions{Kind: kind.Name, Force: true})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(again.Modified) != 0 || len(again.Middleware) != 0 {
+					t.Errorf("second generation of %s changed Register: %+v", kind.Name, again)
+				}
+			}
+		})
+	}
+}
+
+// writeMiddleware writes the middleware.Register function of the
+// framework's projects
+func writeMiddleware(t *testing.T, p Project) {
+	t.Helper()
+	writeFrameworkFile(t, p, middlewareFile)
+}
+
+// writeRoutes writes the routes.Register function of the framework's
+// projects
+func writeRoutes(t *testing.T, p Project) {
+	t.Helper()
+	writeFrameworkFile(t, p, routesFile)
+}
+
+// writeFrameworkFile renders the template of a file of the framework's
+// projects into p.Dir
+func writeFrameworkFile(t *testing.T, p Project, path string) {
+	t.Helper()
+	name := path + templates.TemplateSuffix
+	text, err := fs.ReadFile(templates.Framework(p.Framework), name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := templates.RenderText(name, string(text), p.templateData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path = filepath.Join(p.Dir, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkGolden compares got with testdata/golden/<name>.golden, or rewrites
+// the golden file with -update
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+
+	golden := filepath.Join("testdata", "golden", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run go test ./scaffold -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("generated files differ from %s, run go test ./scaffold -update to accept the change\n%s",
+			golden, diffLines(string(want), got))
+	}
+}
+
+// archiveTree returns the files and empty directories under root as a single
+// text, with every file introduced by a "-- path --" line. Go files are
+// parsed on the way so template syntax errors fail the test.
+func archiveTree(t *testing.T, root string) string {
+	t.Helper()
+
+	var sb strings.Builder
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				sb.WriteString("-- " + rel + "/ --\n")
+			}
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(rel, ".go") {
+			if _, err := parser.ParseFile(fset, rel, content, parser.AllErrors); err != nil {
+				t.Errorf("generated file does not parse: %v", err)
+			}
+		}
+
+		sb.WriteString("-- " + rel + " --\n")
+		sb.Write(content)
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			sb.WriteString("\n")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sb.String()
+}
+
+// diffLines reports the first line where got differs from want
+func diffLines(want, got string) string {
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return fmt.Sprintf("first difference at line %d:\n  want: %s\n  got:  %s", i+1, w, g)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package scaffold
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+
+	"github.com/pol-cova/GoGinit/internal/naming"
+	"github.com/pol-cova/GoGinit/internal/routing"
+	"github.com/pol-cova/GoGinit/templates"
+)
+
+// HandlerOptions describes a handler to generate
+type HandlerOptions struct {
+	// Name of the handler, turned into an exported identifier: get_user
+	// and "get user" both give GetUser
+	Name string
+
+	// Method is the HTTP method of the route, GET when empty
+	Method string
+
+	// Path is the route path, /<kebab-case name> when empty. Path
+	// parameters are written :name or {name}, whatever the framework.
+	Path string
+
+	// Force overwrites the handler and test files when they exist
+	Force bool
+}
+
+// GenerateHandler writes a handler for the framework of the project in
+// internal/handlers/<name>.go, with a test requesting it through the
+// framework's router, and registers its route in routes.Register. When the
+// project has no such function, the returned Routes hold the statement to
+// add instead.
+func GenerateHandler(p Project, opts HandlerOptions) (Generated, error) {
+	data, style, err := handlerData(p, opts)
+	if err != nil {
+		return Generated{}, err
+	}
+
+	files, err := renderGenerator("handler", p.Framework, data)
+	if err != nil {
+		return Generated{}, err
+	}
+	if err := checkDeclarations(p, files); err != nil {
+		return Generated{}, err
+	}
+	written, err := writeGenerated(p, files, opts.Force)
+	if err != nil {
+		return Generated{}, err
+	}
+
+	generated := Generated{Files: written}
+
+	path, _ := routing.ParsePath(data.Path)
+	statements := func(style routing.Style) []string {
+		return []string{style.Statement(data.Method, path, "handlers."+data.Name)}
+	}
+	registered, changed, err := registerRoutes(p, routesFile, style, []string{p.Module + "/internal/handlers"}, statements)
+	if err != nil {
+		return generated, err
+	}
+	if changed {
+		generated.Modified = append(generated.Modified, routesFile)
+	}
+	if !registered {
+		generated.Routes = statements(style)
+	}
+	return generated, nil
+}
+
+// handlerData validates the options and returns the template variables
+func handlerData(p Project, opts HandlerOptions) (templates.HandlerData, routing.Style, error) {
+	data := templates.HandlerData{Data: p.templateData()}
+
+	style, ok := routing.Lookup(p.Framework, p.GoVersion)
+	if !ok {
+		return data, style, fmt.Errorf("generators do not support the %s framework", p.Framework)
+	}
+
+	data.Name = naming.Pascal(opts.Name)
+	if !token.IsIdentifier(data.Name) {
+		return data, style, fmt.Errorf("invalid handler name %q", opts.Name)
+	}
+	data.File = naming.Snake(opts.Name)
+
+	data.Method = strings.ToUpper(opts.Method)
+	if data.Method == "" {
+		data.Method = "GET"
+	}
+	if !validMethod(data.Method) {
+		return data, style, fmt.Errorf("unsupported method %s, use one of %s", opts.Method, strings.Join(routing.Methods, ", "))
+	}
+
+	raw := opts.Path
+	if raw == "" {
+		raw = "/" + naming.Kebab(opts.Name)
+	}
+	path, err := routing.ParsePath(raw)
+	if err != nil {
+		return data, style, err
+	}
+	if err := style.Check(path); err != nil {
+		return data, style, err
+	}
+	data.Path = style.Path(path)
+	data.Sample = path.Sample()
+	for i, name := range path.Params() {
+		data.Params = append(data.Params, templates.Param{Name: name, Sample: routing.SampleValue(i)})
+	}
+	return data, style, nil
+}
+
+// validMethod reports whether routes can be generated for the HTTP method
+func validMethod(method string) bool {
+	for _, m := range routing.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package scaffold
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pol-cova/GoGinit/config"
+	"github.com/pol-cova/GoGinit/runner"
+	"github.com/pol-cova/GoGinit/templates"
+)
+
+// Hook phases reported by HookError
+const (
+	PhasePre  = "pre"
+	PhasePost = "post"
+)
+
+// HookError reports a failed hook. After a failed pre hook nothing was
+// created; after a failed post hook the project is kept.
+type HookError struct {
+	Phase  string      // PhasePre or PhasePost
+	Hook   config.Hook // The hook as configured
+	Output string      // Combined output of the hook
+	Err    error
+}
+
+func (e *HookError) Error() string {
+	msg := fmt.Sprintf("%s hook %q failed: %v", e.Phase, e.Hook, e.Err)
+	if output := strings.TrimRight(e.Output, "\n"); output != "" {
+		msg += "\n" + output
+	}
+	return msg
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// hookCommands renders the hooks into shell commands run in workDir.
+// Relative script paths are relative to scriptDir.
+func hookCommands(phase string, hooks []config.Hook, scriptDir, workDir, projectDir string, spec config.ProjectSpec) ([]runner.Command, error) {
+	frameworkConfig, err := spec.FrameworkConfig()
+	if err != nil {
+		return nil, err
+	}
+	data := templateData(spec, frameworkConfig)
+	env := hookEnv(phase, projectDir, spec)
+
+	commands := make([]runner.Command, 0, len(hooks))
+	for _, hook := range hooks {
+		name, text := "hook "+hook.Run, hook.Run
+		if hook.Script != "" {
+			path := hook.Script
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(scriptDir, path)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, &HookError{Phase: phase, Hook: hook, Err: err}
+			}
+			name, text = hook.Script, string(content)
+		}
+
+		script, err := templates.RenderText(name, text, data)
+		if err != nil {
+			return nil, &HookError{Phase: phase, Hook: hook, Err: err}
+		}
+		cmd := shellCommand(strings.TrimRight(script, "\n"))
+		cmd.Dir, cmd.Env = workDir, env
+		commands = append(commands, cmd)
+	}
+	return commands, nil
+}
+
+// runHooks runs the hooks of a phase in order, stopping at the first failure
+func runHooks(ctx context.Context, r runner.Runner, out io.Writer, phase string, hooks []config.Hook, scriptDir, workDir, projectDir string, spec config.ProjectSpec) error {
+	commands, err := hookCommands(phase, hooks, scriptDir, workDir, projectDir, spec)
+	if err != nil {
+		return err
+	}
+
+	for i, cmd := range commands {
+		fmt.Fprintf(out, "Running %s hook: %s\n", phase, hooks[i])
+		result, err := r.Run(ctx, cmd)
+		if err != nil {
+			if ctx.Err() != nil && phase == PhasePost {
+				return &InterruptedError{Dir: projectDir}
+			} else if ctx.Err() != nil {
+				return ErrInterrupted
+			}
+			return &HookError{Phase: phase, Hook: hooks[i], Output: string(result.Output), Err: err}
+		}
+		out.Write(result.Output)
+	}
+	return nil
+}
+
+// hookEnv exposes the project directory and the scaffolding choices to hooks
+func hookEnv(phase, projectDir string, spec config.ProjectSpec) []string {
+	if abs, err := filepath.Abs(projectDir); err == nil {
+		projectDir = abs
+	}
+	return []string{
+		"GOGINIT_HOOK=" + phase,
+		"GOGINIT_PROJECT_DIR=" + projectDir,
+		"GOGINIT_PROJECT_NAME=" + spec.Name,
+		"GOGINIT_MODULE=" + spec.ModulePath(),
+		"GOGINIT_BINARY=" + spec.BinaryName(),
+		"GOGINIT_FRAMEWORK=" + spec.Framework,
+		"GOGINIT_FRAMEWORK_VERSION=" + spec.FrameworkVersion,
+		"GOGINIT_DATABASE=" + spec.Database,
+		"GOGINIT_GO_VERSION=" + spec.GoVersion,
+	}
+}
+
+// shellCommand returns the command running script with the system shell
+func shellCommand(script string) runner.Command {
+	if runtime.GOOS == "windows" {
+		return runner.Command{Name: "cmd", Args: []string{"/C", script}}
+	}
+	return runner.Command{Name: "sh", Args: []string{"-c", script}}
+}
@@ -0,0 +1,163 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/pol-cova/GoGinit/config"
+	"github.com/pol-cova/GoGinit/runner"
+)
+
+// failingGo is fakeGo failing one command with some output
+type failingGo struct {
+	fakeGo
+	command string
+}
+
+func (g failingGo) Run(ctx context.Context, cmd runner.Command) (runner.Result, error) {
+	if cmd.String() == g.command {
+		return runner.Result{Output: []byte("registry unreachable\n")}, errors.New("exit status 1")
+	}
+	return g.fakeGo.Run(ctx, cmd)
+}
+
+// TestGenerateHooks checks that the user config hooks run before those of
+// the spec, pre hooks before anything is created and post hooks in the
+// project before the verification, rendered and with the GOGINIT variables
+func TestGenerateHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the expected commands are sh commands")
+	}
+
+	// A relative script path is relative to the spec file, not the current directory
+	specDir := t.TempDir()
+	specFile := filepath.Join(specDir, "spec.yaml")
+	files := map[string]string{
+		specFile: "name: example\nframework: chi\nhooks:\n  pre:\n    - script: hooks/check.sh\n  post:\n    - git init {{.ProjectName}}\n",
+		filepath.Join(specDir, "hooks", "check.sh"): "echo checking {{.ModulePath}}\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	spec, err := config.LoadSpec(specFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	userHooks := config.Hooks{Pre: []config.Hook{{Run: "echo user"}}, Post: []config.Hook{{Run: "echo done"}}}
+	result, err := Generate(context.Background(), Options{Spec: spec, SpecDir: specDir, Dir: dir, Runner: fakeGo{}, Hooks: userHooks})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	env := map[string][]string{}
+	for _, cmd := range result.Commands {
+		got = append(got, cmd.Dir+": "+cmd.String())
+		if cmd.Name == "sh" {
+			env[cmd.String()] = cmd.Env
+		}
+	}
+	frameworkConfig, err := spec.FrameworkConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		": go version",
+		dir + ": sh -c echo user",
+		dir + ": sh -c echo checking example",
+		": go mod init example",
+		": go version",
+		": go get " + frameworkConfig.Dependency(),
+		result.Dir + ": sh -c echo done",
+		result.Dir + ": sh -c git init example",
+		result.Dir + ": go build ./...",
+		result.Dir + ": go vet ./...",
+	}
+	// The go commands before the commit run in the staging directory
+	for i := range got {
+		if i < len(want) && strings.HasPrefix(want[i], ": ") && strings.HasSuffix(got[i], want[i]) {
+			got[i] = want[i]
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("commands = %q, want %q", got, want)
+	}
+
+	// The recorded spec keeps the script path as written, to replay elsewhere
+	recorded, err := config.LoadSpec(filepath.Join(result.Dir, config.SpecFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(recorded.Hooks, spec.Hooks) || recorded.Hooks.Pre[0].Script != "hooks/check.sh" {
+		t.Errorf("recorded hooks = %+v, want %+v", recorded.Hooks, spec.Hooks)
+	}
+
+	for command, phase := range map[string]string{"sh -c echo user": "pre", "sh -c git init example": "post"} {
+		for _, v := range []string{"GOGINIT_HOOK=" + phase, "GOGINIT_PROJECT_DIR=" + result.Dir, "GOGINIT_PROJECT_NAME=example", "GOGINIT_FRAMEWORK=chi"} {
+			if !contains(env[command], v) {
+				t.Errorf("%s: environment %q lacks %s", command, env[command], v)
+			}
+		}
+	}
+}
+
+// TestGenerateHookFailure checks that a failing pre hook leaves no project
+// and that the project is kept after a failing post hook
+func TestGenerateHookFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the failing commands are sh commands")
+	}
+	spec := config.ProjectSpec{Name: "example", Framework: "chi"}
+
+	tests := []struct {
+		phase  string
+		exists bool
+	}{
+		{phase: PhasePre, exists: false},
+		{phase: PhasePost, exists: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.phase, func(t *testing.T) {
+			dir := t.TempDir()
+			hooks := config.Hooks{Pre: []config.Hook{{Run: "echo pre"}}, Post: []config.Hook{{Run: "echo post"}}}
+			r := failingGo{command: "sh -c echo " + tt.phase}
+			result, err := Generate(context.Background(), Options{Spec: spec, Dir: dir, Runner: r, Hooks: hooks})
+
+			var hookErr *HookError
+			if !errors.As(err, &hookErr) || hookErr.Phase != tt.phase || hookErr.Output != "registry unreachable\n" {
+				t.Fatalf("got %v, want the %s hook error with its output", err, tt.phase)
+			}
+			if _, err := os.Stat(filepath.Join(dir, spec.Name)); (err == nil) != tt.exists {
+				t.Errorf("project exists = %v, want %v", err == nil, tt.exists)
+			}
+			for _, cmd := range result.Commands {
+				if cmd.String() == "go build ./..." {
+					t.Error("the project was verified after a failed hook")
+				}
+			}
+		})
+	}
+}
+
+// contains reports whether list holds s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
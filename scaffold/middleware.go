@@ -0,0 +1,200 @@
+package scaffold
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pol-cova/GoGinit/internal/astedit"
+	"github.com/pol-cova/GoGinit/internal/naming"
+	"github.com/pol-cova/GoGinit/internal/routing"
+	"github.com/pol-cova/GoGinit/templates"
+)
+
+// middlewareFile holds the Register function attaching the middleware of
+// the generated projects
+const middlewareFile = "internal/middleware/middleware.go"
+
+// MiddlewareKind is a middleware of the catalog of generate middleware
+type MiddlewareKind struct {
+	Name        string // Name on the command line, such as request-id
+	Func        string // Function returning the middleware, such as RequestID
+	Description string
+}
+
+// middlewareKinds is the catalog of middleware, in the order they are best
+// attached: the request ID is set before the access log reads it, and
+// panics are recovered before they reach the logger
+var middlewareKinds = []MiddlewareKind{
+	{Name: "request-id", Func: "RequestID", Description: "give every request an ID, read from or returned in X-Request-ID"},
+	{Name: "logging", Func: "AccessLog", Description: "log every request with structured attributes"},
+	{Name: "recovery", Func: "Recovery", Description: "respond 500 to the requests whose handler panics"},
+	{Name: "cors", Func: "CORS", Description: "answer preflight requests and allow cross-origin calls"},
+	{Name: "timeout", Func: "Timeout", Description: "cancel the requests not served within a deadline"},
+	{Name: "body-limit", Func: "BodyLimit", Description: "refuse request bodies larger than a limit"},
+	{Name: "gzip", Func: "Gzip", Description: "compress the responses of clients accepting gzip"},
+}
+
+// MiddlewareKinds returns the catalog of middleware generate middleware
+// writes
+func MiddlewareKinds() []MiddlewareKind {
+	return append([]MiddlewareKind(nil), middlewareKinds...)
+}
+
+// middlewareKindNames returns the names of the catalog, for error messages
+func middlewareKindNames() []string {
+	names := make([]string, len(middlewareKinds))
+	for i, kind := range middlewareKinds {
+		names[i] = kind.Name
+	}
+	return names
+}
+
+// middlewareFamilies groups the frameworks sharing a middleware signature:
+// the routers built on net/http take func(http.Handler) http.Handler
+var middlewareFamilies = map[string]string{
+	"gin":     "gin",
+	"echo":    "echo",
+	"fiber":   "fiber",
+	"martini": "martini",
+	"chi":     "nethttp",
+	"mux":     "nethttp",
+	"default": "nethttp",
+	"fuego":   "nethttp",
+	"gofr":    "nethttp",
+}
+
+// MiddlewareOptions describes a middleware to generate
+type MiddlewareOptions struct {
+	// Kind is the name of a middleware of the catalog, see MiddlewareKinds
+	Kind string
+
+	// Custom is the name of a middleware to write instead, as an empty
+	// skeleton, turned into an exported identifier: request_timer and
+	// "request timer" both give RequestTimer
+	Custom string
+
+	// Force overwrites the middleware file when it exists
+	Force bool
+}
+
+// GenerateMiddleware writes a middleware of the catalog, or the skeleton of
+// a custom one, in internal/middleware/<name>.go, in the idiom of the
+// project's framework, and attaches it in middleware.Register after the
+// middleware already there. When the project has no such function, the
+// returned Middleware hold the statement to add instead.
+func GenerateMiddleware(p Project, opts MiddlewareOptions) (Generated, error) {
+	family, ok := middlewareFamilies[p.Framework]
+	if !ok {
+		return Generated{}, fmt.Errorf("generators do not support the %s framework", p.Framework)
+	}
+
+	data := templates.MiddlewareData{Data: p.templateData()}
+	variant := family + "/custom"
+	switch {
+	case opts.Kind != "" && opts.Custom != "":
+		return Generated{}, fmt.Errorf("generate either the %s middleware or a custom one", opts.Kind)
+	case opts.Kind == "" && opts.Custom == "":
+		return Generated{}, fmt.Errorf("no middleware to generate, use one of %s or a custom one", strings.Join(middlewareKindNames(), ", "))
+	case opts.Custom != "":
+		data.Name = naming.Pascal(opts.Custom)
+		if !token.IsIdentifier(data.Name) {
+			return Generated{}, fmt.Errorf("invalid middleware name %q", opts.Custom)
+		}
+		data.File = naming.Snake(opts.Custom)
+	default:
+		for _, kind := range middlewareKinds {
+			if kind.Name == opts.Kind {
+				data.Name, data.File = kind.Func, naming.Snake(kind.Func)
+				variant = family + "/" + kind.Name
+			}
+		}
+		if data.Name == "" {
+			return Generated{}, fmt.Errorf("unknown middleware %q, use one of %s or a custom one", opts.Kind, strings.Join(middlewareKindNames(), ", "))
+		}
+	}
+
+	files, err := renderGenerator("middleware", variant, data)
+	if err != nil {
+		return Generated{}, err
+	}
+	if err := checkDeclarations(p, files); err != nil {
+		return Generated{}, err
+	}
+	written, err := writeGenerated(p, files, opts.Force)
+	if err != nil {
+		return Generated{}, err
+	}
+
+	generated := Generated{Files: written}
+	attached, changed, err := attachMiddleware(p, data.Name)
+	if err != nil {
+		return generated, err
+	}
+	if changed {
+		generated.Modified = append(generated.Modified, middlewareFile)
+	}
+	if !attached {
+		generated.Middleware = []string{middlewareStatement(p.Framework, "", data.Name)}
+	}
+	return generated, nil
+}
+
+// attachMiddleware adds a middleware to middleware.Register. It reports
+// whether the middleware is attached, false when the project has no such
+// function, and whether the file changed.
+func attachMiddleware(p Project, name string) (attached, changed bool, err error) {
+	if p.Framework != "default" {
+		statements := func(style routing.Style) []string {
+			return []string{middlewareStatement(p.Framework, style.Router, name)}
+		}
+		return registerRoutes(p, middlewareFile, routing.Style{}, nil, statements)
+	}
+
+	// Register of the standard library projects wraps the handler it returns
+	f, err := astedit.ParseFile(filepath.Join(p.Dir, filepath.FromSlash(middlewareFile)))
+	if os.IsNotExist(err) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	router, ok := f.Router()
+	if !ok || router.Func != "Register" {
+		return false, false, nil
+	}
+	wrapped, err := f.WrapHandler(router, name)
+	if err != nil || !wrapped || !f.Changed() {
+		return wrapped, false, err
+	}
+	if err := f.Write(); err != nil {
+		return false, false, fmt.Errorf("error attaching middleware in %s: %w", middlewareFile, err)
+	}
+	return true, true, nil
+}
+
+// middlewareStatement returns the statement attaching a middleware to the
+// router of a framework, named as in the generated projects when router is
+// empty
+func middlewareStatement(framework, router, name string) string {
+	defaults := map[string]string{"echo": "e", "fiber": "app", "fuego": "s", "gofr": "app", "martini": "m", "default": "h"}
+	if router == "" {
+		router = defaults[framework]
+		if router == "" {
+			router = "r"
+		}
+	}
+	switch framework {
+	case "gin", "fiber", "martini":
+		return router + ".Use(" + name + "())"
+	case "fuego":
+		return "fuego.Use(" + router + ", " + name + ")"
+	case "gofr":
+		return router + ".UseMiddleware(" + name + ")"
+	case "default":
+		return router + " = " + name + "(" + router + ")"
+	}
+	return router + ".Use(" + name + ")"
+}
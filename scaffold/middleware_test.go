@@ -0,0 +1,73 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestGenerateMiddlewareUnattached checks the statement returned when
+// middleware.Register cannot attach the middleware, and the options refused
+func TestGenerateMiddlewareUnattached(t *testing.T) {
+	tests := []struct {
+		framework string
+		src       string // middleware.go, none when empty
+		opts      MiddlewareOptions
+		want      []string
+	}{
+		{framework: "echo", opts: MiddlewareOptions{Kind: "gzip"}, want: []string{"e.Use(Gzip)"}},
+		{framework: "fiber", opts: MiddlewareOptions{Custom: "audit"}, want: []string{"app.Use(Audit())"}},
+		{framework: "fuego", opts: MiddlewareOptions{Kind: "cors"}, want: []string{"fuego.Use(s, CORS)"}},
+		{
+			framework: "default",
+			src: `package middleware
+
+import "net/http"
+
+// Register returns the handler unchanged
+func Register(h http.Handler) http.Handler {
+	return http.NotFoundHandler()
+}
+`,
+			opts: MiddlewareOptions{Kind: "request-id"},
+			want: []string{"h = RequestID(h)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.framework, func(t *testing.T) {
+			p := Project{Dir: t.TempDir(), Module: "example.com/example", Framework: tt.framework}
+			if tt.src != "" {
+				path := filepath.Join(p.Dir, filepath.FromSlash(middlewareFile))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(tt.src), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			generated, err := GenerateMiddleware(p, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(generated.Files) != 1 || len(generated.Modified) != 0 || !reflect.DeepEqual(generated.Middleware, tt.want) {
+				t.Errorf("got %+v, want the file written and %q to attach", generated, tt.want)
+			}
+		})
+	}
+
+	p := Project{Dir: t.TempDir(), Module: "example.com/example", Framework: "chi"}
+	for _, opts := range []MiddlewareOptions{{}, {Kind: "auth"}, {Kind: "cors", Custom: "audit"}, {Custom: "1st"}} {
+		if _, err := GenerateMiddleware(p, opts); err == nil {
+			t.Errorf("%+v generated a middleware", opts)
+		}
+	}
+	if entries, _ := os.ReadDir(p.Dir); len(entries) != 0 {
+		t.Errorf("refused options wrote %d entries", len(entries))
+	}
+	if _, err := GenerateMiddleware(Project{Dir: p.Dir, Framework: "rails"}, MiddlewareOptions{Kind: "cors"}); err == nil || !strings.Contains(err.Error(), "rails") {
+		t.Errorf("got %v, want the framework refused", err)
+	}
+}
@@ -0,0 +1,267 @@
+package scaffold
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/pol-cova/GoGinit/config"
+	"github.com/pol-cova/GoGinit/internal/naming"
+	"github.com/pol-cova/GoGinit/templates"
+)
+
+// Primary key types of ModelOptions.PrimaryKey
+const (
+	PrimaryKeyInt  = "int"  // INTEGER assigned by the database
+	PrimaryKeyUUID = "uuid" // TEXT holding a UUID set by the application
+)
+
+// Field modifiers, written after the type: email:string:unique
+const (
+	ModifierNull   = "null"   // Nullable column, pointer field
+	ModifierUnique = "unique" // UNIQUE constraint
+	ModifierPK     = "pk"     // Primary key, instead of the generated id
+)
+
+// ModelOptions describes a model to generate
+type ModelOptions struct {
+	// Name of the model, turned into an exported identifier: user_account
+	// gives UserAccount, stored in the user_accounts table
+	Name string
+
+	// Fields are written name:type followed by modifiers, such as
+	// email:string:unique. See FieldTypes and the Modifier constants.
+	Fields []string
+
+	// PrimaryKey is the type of the id column added when no field is
+	// marked pk, PrimaryKeyInt when empty
+	PrimaryKey string
+
+	// NoTimestamps leaves out the created_at and updated_at columns
+	NoTimestamps bool
+
+	// Force overwrites the model and schema files when they exist
+	Force bool
+}
+
+// fieldType is a field type of the model generator, with the matching Go
+// type and column type of every supported database
+type fieldType struct {
+	Go     string
+	SQLite string
+}
+
+// fieldTypes are the field types accepted by GenerateModel
+var fieldTypes = map[string]fieldType{
+	"string":  {"string", "TEXT"},
+	"text":    {"string", "TEXT"},
+	"uuid":    {"string", "TEXT"},
+	"int":     {"int", "INTEGER"},
+	"int32":   {"int32", "INTEGER"},
+	"int64":   {"int64", "INTEGER"},
+	"uint":    {"uint", "INTEGER"},
+	"float":   {"float64", "REAL"},
+	"float32": {"float32", "REAL"},
+	"float64": {"float64", "REAL"},
+	"bool":    {"bool", "BOOLEAN"},
+	"time":    {"time.Time", "DATETIME"},
+	"date":    {"time.Time", "DATE"},
+	"bytes":   {"[]byte", "BLOB"},
+}
+
+// sqlKeywords are the keywords of SQLite. The generated SQL does not quote
+// the table and column names, so they cannot be keywords.
+var sqlKeywords = map[string]bool{}
+
+func init() {
+	for _, keyword := range strings.Fields(`
+		ABORT ACTION ADD AFTER ALL ALTER ALWAYS ANALYZE AND AS ASC ATTACH
+		AUTOINCREMENT BEFORE BEGIN BETWEEN BY CASCADE CASE CAST CHECK COLLATE
+		COLUMN COMMIT CONFLICT CONSTRAINT CREATE CROSS CURRENT CURRENT_DATE
+		CURRENT_TIME CURRENT_TIMESTAMP DATABASE DEFAULT DEFERRABLE DEFERRED
+		DELETE DESC DETACH DISTINCT DO DROP EACH ELSE END ESCAPE EXCEPT EXCLUDE
+		EXCLUSIVE EXISTS EXPLAIN FAIL FILTER FIRST FOLLOWING FOR FOREIGN FROM
+		FULL GENERATED GLOB GROUP GROUPS HAVING IF IGNORE IMMEDIATE IN INDEX
+		INDEXED INITIALLY INNER INSERT INSTEAD INTERSECT INTO IS ISNULL JOIN
+		KEY LAST LEFT LIKE LIMIT MATCH MATERIALIZED NATURAL NO NOT NOTHING
+		NOTNULL NULL NULLS OF OFFSET ON OR ORDER OTHERS OUTER OVER PARTITION
+		PLAN PRAGMA PRECEDING PRIMARY QUERY RAISE RANGE RECURSIVE REFERENCES
+		REGEXP REINDEX RELEASE RENAME REPLACE RESTRICT RETURNING RIGHT ROLLBACK
+		ROW ROWS SAVEPOINT SELECT SET TABLE TEMP TEMPORARY THEN TIES TO
+		TRANSACTION TRIGGER UNBOUNDED UNION UNIQUE UPDATE USING VACUUM VALUES
+		VIEW VIRTUAL WHEN WHERE WINDOW WITH WITHOUT`) {
+		sqlKeywords[strings.ToLower(keyword)] = true
+	}
+}
+
+// FieldTypes returns the field types accepted by GenerateModel, sorted
+func FieldTypes() []string {
+	types := make([]string, 0, len(fieldTypes))
+	for name := range fieldTypes {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// GenerateModel writes a model struct in pkg/models/<name>.go with JSON and
+// db tags. When the project has a database, the CREATE TABLE statement of
+// the model is written in pkg/db/schema/<table>.sql.
+func GenerateModel(p Project, opts ModelOptions) (Generated, error) {
+	data, err := modelData(p, opts)
+	if err != nil {
+		return Generated{}, err
+	}
+
+	files, err := renderGenerator("model", "models", data)
+	if err != nil {
+		return Generated{}, err
+	}
+	if p.Database != "" {
+		schema, err := renderGenerator("model", p.Database, data)
+		if err != nil {
+			return Generated{}, err
+		}
+		for name, content := range schema {
+			files[name] = content
+		}
+	}
+
+	if err := checkDeclarations(p, files); err != nil {
+		return Generated{}, err
+	}
+	written, err := writeGenerated(p, files, opts.Force)
+	if err != nil {
+		return Generated{}, err
+	}
+	return Generated{Files: written}, nil
+}
+
+// modelData validates the options and returns the template variables
+func modelData(p Project, opts ModelOptions) (templates.ModelData, error) {
+	data := templates.ModelData{Data: p.templateData()}
+
+	data.Name = naming.Pascal(opts.Name)
+	if !token.IsIdentifier(data.Name) {
+		return data, fmt.Errorf("invalid model name %q", opts.Name)
+	}
+	data.File = naming.Snake(opts.Name)
+	data.Table = naming.Plural(data.File)
+	if p.Database != "" && sqlKeywords[data.Table] {
+		return data, fmt.Errorf("model %s cannot be stored in the %s table, %s is an SQL keyword, rename the model", opts.Name, data.Table, data.Table)
+	}
+
+	fields, err := parseFields(p.Database, opts.Fields)
+	if err != nil {
+		return data, err
+	}
+
+	hasPK := false
+	for _, f := range fields {
+		hasPK = hasPK || f.PrimaryKey
+	}
+	if !hasPK {
+		id, err := primaryKeyField(p.Database, opts.PrimaryKey)
+		if err != nil {
+			return data, err
+		}
+		fields = append([]templates.Field{id}, fields...)
+	}
+	if !opts.NoTimestamps {
+		for _, column := range []string{"created_at", "updated_at"} {
+			fields = append(fields, templates.Field{
+				Name:    naming.Pascal(column),
+				Column:  column,
+				Type:    "time.Time",
+				SQLType: columnType(p.Database, fieldTypes["time"]),
+				Default: "CURRENT_TIMESTAMP",
+			})
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, f := range fields {
+		if seen[f.Column] {
+			return data, fmt.Errorf("duplicate field %s, the id and timestamp fields are added unless a field is marked %s or timestamps are disabled", f.Column, ModifierPK)
+		}
+		seen[f.Column] = true
+		if strings.Contains(f.Type, "time.") && len(data.Imports) == 0 {
+			data.Imports = append(data.Imports, "time")
+		}
+	}
+	data.Fields = fields
+	return data, nil
+}
+
+// parseFields parses name:type[:modifier...] field definitions
+func parseFields(database string, defs []string) ([]templates.Field, error) {
+	var fields []templates.Field
+	pk := ""
+	for _, def := range defs {
+		parts := strings.Split(def, ":")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("invalid field %q, write it name:type", def)
+		}
+
+		f := templates.Field{Name: naming.Pascal(parts[0]), Column: naming.Snake(parts[0])}
+		if !token.IsIdentifier(f.Name) {
+			return nil, fmt.Errorf("invalid field name %q", parts[0])
+		}
+		if database != "" && sqlKeywords[f.Column] {
+			return nil, fmt.Errorf("field %s cannot be stored in the %s column, %s is an SQL keyword, rename the field", parts[0], f.Column, f.Column)
+		}
+		typ, ok := fieldTypes[strings.ToLower(parts[1])]
+		if !ok {
+			return nil, fmt.Errorf("unknown type %q for field %s, use one of %s", parts[1], parts[0], strings.Join(FieldTypes(), ", "))
+		}
+		f.Type, f.SQLType = typ.Go, columnType(database, typ)
+
+		for _, modifier := range parts[2:] {
+			switch strings.ToLower(modifier) {
+			case ModifierNull, "nullable":
+				f.Null = true
+			case ModifierUnique:
+				f.Unique = true
+			case ModifierPK:
+				if pk != "" {
+					return nil, fmt.Errorf("fields %s and %s are both marked %s", pk, parts[0], ModifierPK)
+				}
+				f.PrimaryKey, pk = true, parts[0]
+			default:
+				return nil, fmt.Errorf("unknown modifier %q for field %s, use %s, %s or %s", modifier, parts[0], ModifierNull, ModifierUnique, ModifierPK)
+			}
+		}
+		if f.Null && f.PrimaryKey {
+			return nil, fmt.Errorf("primary key %s cannot be %s", parts[0], ModifierNull)
+		}
+		if f.Null && !strings.HasPrefix(f.Type, "[]") {
+			f.Type = "*" + f.Type
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// primaryKeyField returns the id field added to models without a primary key
+func primaryKeyField(database, kind string) (templates.Field, error) {
+	id := templates.Field{Name: "ID", Column: "id", PrimaryKey: true}
+	switch kind {
+	case "", PrimaryKeyInt:
+		id.Type, id.SQLType, id.AutoIncrement = "int64", columnType(database, fieldTypes["int64"]), true
+	case PrimaryKeyUUID:
+		id.Type, id.SQLType = "string", columnType(database, fieldTypes["uuid"])
+	default:
+		return id, fmt.Errorf("unknown primary key type %q, use %s or %s", kind, PrimaryKeyInt, PrimaryKeyUUID)
+	}
+	return id, nil
+}
+
+// columnType returns the column type of a field type in the database, empty
+// when the project has no database
+func columnType(database string, typ fieldType) string {
+	if database == config.DatabaseSQLite {
+		return typ.SQLite
+	}
+	return ""
+}
@@ -0,0 +1,49 @@
+package scaffold
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pol-cova/GoGinit/config"
+)
+
+// TestGenerateModelKeywords checks that table and column names which are
+// SQL keywords are refused in projects with a database, before anything is
+// written
+func TestGenerateModelKeywords(t *testing.T) {
+	tests := []struct {
+		database string
+		opts     ModelOptions
+		want     string // Part of the error, empty when generated
+	}{
+		{database: config.DatabaseSQLite, opts: ModelOptions{Name: "invoice", Fields: []string{"order:int"}}, want: "order is an SQL keyword"},
+		{database: config.DatabaseSQLite, opts: ModelOptions{Name: "invoice", Fields: []string{"title:string", "group:string"}}, want: "group is an SQL keyword"},
+		{database: config.DatabaseSQLite, opts: ModelOptions{Name: "invoice", Fields: []string{"range:int"}}, want: "range is an SQL keyword"},
+		{database: config.DatabaseSQLite, opts: ModelOptions{Name: "invoice", Fields: []string{"Select:string"}}, want: "select is an SQL keyword"},
+		{database: config.DatabaseSQLite, opts: ModelOptions{Name: "group"}, want: "groups is an SQL keyword"},
+		{database: config.DatabaseSQLite, opts: ModelOptions{Name: "invoice", Fields: []string{"order_number:int", "range_start:int"}}},
+		{opts: ModelOptions{Name: "invoice", Fields: []string{"order:int"}}},
+	}
+	for _, tt := range tests {
+		p := Project{Dir: t.TempDir(), Module: "example.com/example", Framework: "chi", Database: tt.database}
+		_, err := GenerateModel(p, tt.opts)
+		checkError(t, strings.Join(tt.opts.Fields, " "), err, tt.want)
+		if entries, _ := os.ReadDir(p.Dir); tt.want != "" && len(entries) != 0 {
+			t.Errorf("%+v: the refused model wrote %d entries", tt.opts, len(entries))
+		}
+	}
+}
+
+// checkError checks that err holds want, or is nil when want is empty
+func checkError(t *testing.T, input string, err error, want string) {
+	t.Helper()
+	switch {
+	case want == "" && err != nil:
+		t.Errorf("%q: unexpected error %v", input, err)
+	case want != "" && err == nil:
+		t.Errorf("%q: got no error, want %q", input, want)
+	case want != "" && !strings.Contains(err.Error(), want):
+		t.Errorf("%q: got %v, want %q", input, err, want)
+	}
+}
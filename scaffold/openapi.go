@@ -0,0 +1,634 @@
+package scaffold
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pol-cova/GoGinit/internal/naming"
+	"github.com/pol-cova/GoGinit/internal/openapi"
+	"github.com/pol-cova/GoGinit/internal/routing"
+	"github.com/pol-cova/GoGinit/templates"
+)
+
+// openAPIModels is the file holding the types of the schemas of an OpenAPI
+// document, rewritten by every generation
+const openAPIModels = "pkg/models/openapi.go"
+
+// generatedHeader starts the files rewritten by the generators, following
+// the convention recognized by Go tools
+const generatedHeader = "// Code generated by goginit"
+
+// OpenAPIOptions describes the OpenAPI document to generate code from
+type OpenAPIOptions struct {
+	// Spec is the path of the OpenAPI 3 document, in YAML or JSON
+	Spec string
+
+	// Force overwrites the handler files of operations whose handler is not
+	// declared yet. Handlers already declared are never overwritten, and
+	// pkg/models/openapi.go is rewritten whatever Force when it was
+	// generated, and never when it was written by hand.
+	Force bool
+}
+
+// GenerateOpenAPI generates the server side of an OpenAPI 3 document: the
+// types of its schemas and of the inline request and response bodies in
+// pkg/models/openapi.go, a handler stub for every operation in
+// internal/handlers, binding the JSON request body and responding 501 Not
+// Implemented, and the routes of the operations in routes.Register.
+//
+// Generation can be repeated when the document changes: the models file is
+// rewritten, and operations whose handler is already declared in
+// internal/handlers keep it, the handler being listed in Kept. Routes
+// already registered are not added twice.
+func GenerateOpenAPI(p Project, opts OpenAPIOptions) (Generated, error) {
+	style, ok := routing.Lookup(p.Framework, p.GoVersion)
+	if !ok {
+		return Generated{}, fmt.Errorf("generators do not support the %s framework", p.Framework)
+	}
+	doc, err := openapi.Load(opts.Spec)
+	if err != nil {
+		return Generated{}, err
+	}
+
+	types := newSchemaTypes(doc)
+	for _, schema := range doc.Components.Schemas {
+		if err := types.declare(naming.Pascal(schema.Name), schema.Value, []string{naming.Pascal(schema.Name) + " is the " + schema.Name + " schema of the API"}); err != nil {
+			return Generated{}, fmt.Errorf("schema %s: %w", schema.Name, err)
+		}
+	}
+	ops, err := operations(p, doc, style, types)
+	if err != nil {
+		return Generated{}, err
+	}
+
+	var generated Generated
+	if len(types.decls) > 0 {
+		data := templates.SchemaData{
+			Data:   p.templateData(),
+			Source: filepath.Base(opts.Spec),
+			Types:  types.decls,
+		}
+		for _, decl := range types.decls {
+			for _, f := range decl.Fields {
+				if strings.Contains(f.Type, "time.Time") {
+					data.Imports = []string{"time"}
+				}
+			}
+			if strings.Contains(decl.Type, "time.Time") {
+				data.Imports = []string{"time"}
+			}
+		}
+		files, err := renderGenerator("openapi", "models", data)
+		if err != nil {
+			return Generated{}, err
+		}
+		if err := checkGenerated(p, openAPIModels, false); err != nil {
+			return Generated{}, err
+		}
+		if err := checkDeclarations(p, files); err != nil {
+			return Generated{}, err
+		}
+		previous, err := os.ReadFile(filepath.Join(p.Dir, filepath.FromSlash(openAPIModels)))
+		switch {
+		case err == nil && string(previous) == files[openAPIModels]:
+		case err == nil:
+			if _, err := writeGenerated(p, files, true); err != nil {
+				return Generated{}, err
+			}
+			generated.Modified = append(generated.Modified, openAPIModels)
+		default:
+			if generated.Files, err = writeGenerated(p, files, true); err != nil {
+				return Generated{}, err
+			}
+		}
+	}
+
+	declared, err := packageNames(filepath.Join(p.Dir, "internal", "handlers"))
+	if err != nil {
+		return generated, err
+	}
+	stubs := map[string]string{}
+	for _, op := range ops {
+		if declared[op.Name] {
+			generated.Kept = append(generated.Kept, "handlers."+op.Name)
+			continue
+		}
+		files, err := renderGenerator("openapi", p.Framework, op)
+		if err != nil {
+			return generated, err
+		}
+		for name, content := range files {
+			if _, ok := stubs[name]; ok {
+				return generated, fmt.Errorf("operations %s and another one are both written to %s, give them distinct operationIds", op.Name, name)
+			}
+			stubs[name] = content
+		}
+	}
+	if err := checkDeclarations(p, stubs); err != nil {
+		return generated, err
+	}
+	written, err := writeGenerated(p, stubs, opts.Force)
+	if err != nil {
+		return generated, err
+	}
+	generated.Files = append(generated.Files, written...)
+
+	if len(ops) == 0 {
+		return generated, nil
+	}
+	statements := func(style routing.Style) []string {
+		var stmts []string
+		for _, op := range ops {
+			path, _ := routing.ParsePath(op.Path)
+			stmts = append(stmts, style.Statement(op.Method, path, "handlers."+op.Name))
+		}
+		return stmts
+	}
+	registered, changed, err := registerRoutes(p, routesFile, style, []string{p.Module + "/internal/handlers"}, statements)
+	if err != nil {
+		return generated, err
+	}
+	if changed {
+		generated.Modified = append(generated.Modified, routesFile)
+	}
+	if !registered {
+		generated.Routes = statements(style)
+	}
+	return generated, nil
+}
+
+// operations returns the template variables of the handler stubs of the
+// operations of the document, declaring the types of their inline bodies.
+// Path holds the route path in the syntax of the framework.
+func operations(p Project, doc *openapi.Document, style routing.Style, types *schemaTypes) ([]templates.OperationData, error) {
+	var ops []templates.OperationData
+	names := map[string]string{}
+	for _, item := range doc.Paths {
+		for _, entry := range item.Value.Operations() {
+			method, op := entry.Name, entry.Value
+			where := method + " " + item.Name
+			if !validMethod(method) {
+				return nil, fmt.Errorf("%s: routes cannot be generated for the %s method", where, method)
+			}
+
+			data := templates.OperationData{HandlerData: templates.HandlerData{Data: p.templateData(), Method: method}}
+			data.Name = naming.Pascal(op.OperationID)
+			if op.OperationID == "" {
+				data.Name = operationName(method, item.Name)
+			}
+			if !token.IsIdentifier(data.Name) {
+				return nil, fmt.Errorf("%s: invalid handler name %q, set an operationId", where, data.Name)
+			}
+			if other, ok := names[data.Name]; ok {
+				return nil, fmt.Errorf("%s and %s both have the handler name %s, give them distinct operationIds", other, where, data.Name)
+			}
+			names[data.Name] = where
+			data.File = naming.Snake(data.Name)
+
+			path, err := routing.ParsePath(item.Name)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", where, err)
+			}
+			if err := style.Check(path); err != nil {
+				return nil, fmt.Errorf("%s: %w", where, err)
+			}
+			data.Path = style.Path(path)
+			data.Sample = path.Sample()
+			for i, name := range path.Params() {
+				data.Params = append(data.Params, templates.Param{Name: name, Sample: routing.SampleValue(i)})
+			}
+
+			query, err := queryParams(doc, append(append([]openapi.Parameter{}, item.Value.Parameters...), op.Parameters...))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", where, err)
+			}
+			reserved := map[string]bool{"error": true, "body": true}
+			for _, name := range path.Params() {
+				reserved[name] = true
+			}
+			for _, name := range query {
+				if !reserved[name] {
+					data.Query = append(data.Query, name)
+					reserved[name] = true
+				}
+			}
+
+			for _, text := range []string{op.Summary, op.Description} {
+				if text = strings.TrimSpace(text); text != "" {
+					if len(data.Doc) > 0 {
+						data.Doc = append(data.Doc, "")
+					}
+					data.Doc = append(data.Doc, sentences(text)...)
+				}
+			}
+
+			if data.Body, err = types.requestBody(data.Name, op); err != nil {
+				return nil, fmt.Errorf("%s: %w", where, err)
+			}
+			if data.Response, err = types.response(data.Name, op); err != nil {
+				return nil, fmt.Errorf("%s: %w", where, err)
+			}
+			data.BodyModels = strings.Contains(data.Body, "models.")
+			data.ResponseModels = strings.Contains(data.Response, "models.")
+			ops = append(ops, data)
+		}
+	}
+	return ops, nil
+}
+
+// operationName names the handler of an operation without operationId
+// after its method and path: GET /pets/{petId} gives GetPetsByPetID
+func operationName(method, path string) string {
+	words := []string{strings.ToLower(method)}
+	for _, elem := range strings.Split(path, "/") {
+		if name, ok := strings.CutPrefix(strings.TrimSuffix(elem, "}"), "{"); ok {
+			words = append(words, "by", name)
+		} else if elem != "" {
+			words = append(words, elem)
+		}
+	}
+	return naming.Pascal(strings.Join(words, " "))
+}
+
+// queryParams returns the names of the query parameters, those of the
+// operation overriding those of its path
+func queryParams(doc *openapi.Document, params []openapi.Parameter) ([]string, error) {
+	var names []string
+	seen := map[string]bool{}
+	for i := len(params) - 1; i >= 0; i-- {
+		param, err := doc.Parameter(params[i])
+		if err != nil {
+			return nil, err
+		}
+		if param.In == "query" && !seen[param.Name] {
+			seen[param.Name] = true
+			names = append([]string{param.Name}, names...)
+		}
+	}
+	return names, nil
+}
+
+// checkGenerated fails when a file the generators rewrite exists without
+// their header on its first line, having been written by hand, unless
+// force is set
+func checkGenerated(p Project, name string, force bool) error {
+	content, err := os.ReadFile(filepath.Join(p.Dir, filepath.FromSlash(name)))
+	if os.IsNotExist(err) || force {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	first, _, _ := strings.Cut(string(content), "\n")
+	if !strings.Contains(first, strings.TrimPrefix(generatedHeader, "// ")) {
+		return fmt.Errorf("%w: %s was not generated by goginit", ErrExists, name)
+	}
+	return nil
+}
+
+// packageNames returns the top-level names declared by the non-test Go
+// files of a directory
+func packageNames(dir string) (map[string]bool, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	fset := token.NewFileSet()
+	for _, match := range matches {
+		if strings.HasSuffix(match, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, match, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range topLevelNames(f) {
+			names[name] = true
+		}
+	}
+	return names, nil
+}
+
+// schemaTypes declares the Go types of the schemas of a document
+type schemaTypes struct {
+	doc      *openapi.Document
+	decls    []templates.TypeDecl
+	declared map[string]bool
+}
+
+func newSchemaTypes(doc *openapi.Document) *schemaTypes {
+	return &schemaTypes{doc: doc, declared: map[string]bool{}}
+}
+
+// declare declares a type for a schema
+func (t *schemaTypes) declare(name string, s *openapi.Schema, doc []string) error {
+	if !token.IsIdentifier(name) || !token.IsExported(name) {
+		return fmt.Errorf("invalid type name %q", name)
+	}
+	if t.declared[name] {
+		return fmt.Errorf("type %s is declared twice, rename the schema or the operation", name)
+	}
+	t.declared[name] = true
+
+	decl := templates.TypeDecl{Name: name, Doc: doc}
+	if s != nil && strings.TrimSpace(s.Description) != "" {
+		decl.Doc = append(decl.Doc, "")
+		decl.Doc = append(decl.Doc, sentences(s.Description)...)
+	}
+	// Nested inline types are declared after the type using them
+	i := len(t.decls)
+	t.decls = append(t.decls, decl)
+
+	if s == nil || !t.isStruct(s) {
+		typ, err := t.goType(s, name, "")
+		if err != nil {
+			return err
+		}
+		t.decls[i].Type = typ
+		return nil
+	}
+
+	props, required, err := t.properties(s)
+	if err != nil {
+		return err
+	}
+	fields := make([]templates.SchemaField, 0, len(props))
+	seen := map[string]string{}
+	for _, prop := range props {
+		field := templates.SchemaField{Name: naming.Pascal(prop.Name), JSON: prop.Name}
+		if !token.IsIdentifier(field.Name) {
+			return fmt.Errorf("property %q of %s cannot be turned into a Go field", prop.Name, name)
+		}
+		if other, ok := seen[field.Name]; ok {
+			return fmt.Errorf("properties %s and %s of %s are both the field %s", other, prop.Name, name, field.Name)
+		}
+		seen[field.Name] = prop.Name
+
+		if field.Type, err = t.goType(prop.Value, name+field.Name, ""); err != nil {
+			return fmt.Errorf("property %s of %s: %w", prop.Name, name, err)
+		}
+		if prop.Value != nil {
+			// A struct cannot hold itself, a reference back to it is a pointer
+			nullable := prop.Value.IsNullable() || t.holds(prop.Value, s, map[*openapi.Schema]bool{})
+			if nullable && !pointerLike(field.Type) {
+				field.Type = "*" + field.Type
+			}
+			if text := strings.TrimSpace(prop.Value.Description); text != "" {
+				field.Doc = sentences(text)
+			}
+		}
+		if !required[prop.Name] {
+			field.JSON += ",omitempty"
+		}
+		fields = append(fields, field)
+	}
+	t.decls[i].Fields = fields
+	return nil
+}
+
+// isStruct reports whether a struct is declared for a schema: an object
+// with properties, or the composition of schemas with allOf
+func (t *schemaTypes) isStruct(s *openapi.Schema) bool {
+	if s.Ref != "" {
+		return false
+	}
+	if len(s.AllOf) == 1 && s.AllOf[0].Ref != "" && len(s.Properties) == 0 {
+		// allOf: [$ref] is an alias
+		return false
+	}
+	return len(s.Properties) > 0 || len(s.AllOf) > 0
+}
+
+// holds reports whether the Go type of s holds the struct of target by
+// value, directly or through the fields of other structs, as opposed to
+// through pointers, slices and maps
+func (t *schemaTypes) holds(s, target *openapi.Schema, seen map[*openapi.Schema]bool) bool {
+	if s == target {
+		return true
+	}
+	if s == nil || seen[s] {
+		return false
+	}
+	seen[s] = true
+
+	ref := s.Ref
+	if len(s.AllOf) == 1 && len(s.Properties) == 0 {
+		ref = s.AllOf[0].Ref
+	}
+	if ref != "" {
+		resolved, err := t.schema(ref)
+		return err == nil && t.holds(resolved, target, seen)
+	}
+	if !t.isStruct(s) {
+		return false
+	}
+	props, _, err := t.properties(s)
+	if err != nil {
+		return false
+	}
+	for _, prop := range props {
+		if prop.Value != nil && !prop.Value.IsNullable() && t.holds(prop.Value, target, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// properties returns the properties of an object schema, with those of
+// the schemas it is composed of with allOf, and the required ones
+func (t *schemaTypes) properties(s *openapi.Schema) (openapi.Ordered[*openapi.Schema], map[string]bool, error) {
+	var props openapi.Ordered[*openapi.Schema]
+	required := map[string]bool{}
+	var collect func(s *openapi.Schema, depth int) error
+	collect = func(s *openapi.Schema, depth int) error {
+		if depth > 32 {
+			return fmt.Errorf("allOf references form a cycle")
+		}
+		if s.Ref != "" {
+			resolved, err := t.schema(s.Ref)
+			if err != nil {
+				return err
+			}
+			return collect(resolved, depth+1)
+		}
+		for _, sub := range s.AllOf {
+			if err := collect(sub, depth+1); err != nil {
+				return err
+			}
+		}
+		for _, prop := range s.Properties {
+			if _, ok := props.Get(prop.Name); !ok {
+				props = append(props, prop)
+			}
+		}
+		for _, name := range s.Required {
+			required[name] = true
+		}
+		return nil
+	}
+	err := collect(s, 0)
+	return props, required, err
+}
+
+// schema resolves a reference to a component schema
+func (t *schemaTypes) schema(ref string) (*openapi.Schema, error) {
+	name, err := openapi.ComponentName(ref, "schemas")
+	if err != nil {
+		return nil, err
+	}
+	s, ok := t.doc.Components.Schemas.Get(name)
+	if !ok || s == nil {
+		return nil, fmt.Errorf("schema %s is not defined", ref)
+	}
+	return s, nil
+}
+
+// goType returns the Go type of a schema, qualifying the declared types
+// with pkg. Inline objects are declared under name.
+func (t *schemaTypes) goType(s *openapi.Schema, name, pkg string) (string, error) {
+	if s == nil {
+		return "any", nil
+	}
+	if s.Ref != "" {
+		ref, err := openapi.ComponentName(s.Ref, "schemas")
+		if err != nil {
+			return "", err
+		}
+		if _, err := t.schema(s.Ref); err != nil {
+			return "", err
+		}
+		return pkg + naming.Pascal(ref), nil
+	}
+	if len(s.AllOf) == 1 && len(s.Properties) == 0 {
+		return t.goType(s.AllOf[0], name, pkg)
+	}
+	if t.isStruct(s) {
+		if err := t.declare(name, s, []string{name + " is an object of the API"}); err != nil {
+			return "", err
+		}
+		return pkg + name, nil
+	}
+	if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		return "any", nil
+	}
+
+	var types []string
+	for _, typ := range s.Type {
+		if typ != "null" {
+			types = append(types, typ)
+		}
+	}
+	if len(types) != 1 {
+		return "any", nil
+	}
+	switch types[0] {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			return "time.Time", nil
+		case "byte":
+			return "[]byte", nil
+		}
+		return "string", nil
+	case "integer":
+		switch s.Format {
+		case "int32":
+			return "int32", nil
+		case "int64":
+			return "int64", nil
+		}
+		return "int", nil
+	case "number":
+		if s.Format == "float" {
+			return "float32", nil
+		}
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		item, err := t.goType(s.Items, name+"Item", pkg)
+		return "[]" + item, err
+	case "object":
+		if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+			value, err := t.goType(s.AdditionalProperties.Schema, name+"Value", pkg)
+			return "map[string]" + value, err
+		}
+		return "map[string]any", nil
+	}
+	return "any", nil
+}
+
+// requestBody returns the Go type of the JSON request body of an
+// operation, declaring <Name>Request for an inline object
+func (t *schemaTypes) requestBody(name string, op *openapi.Operation) (string, error) {
+	body, err := t.doc.RequestBody(op.RequestBody)
+	if err != nil || body == nil {
+		return "", err
+	}
+	schema := openapi.JSONSchema(body.Content)
+	if schema == nil {
+		return "", nil
+	}
+	return t.bodyType(schema, name+"Request", "request body of "+name)
+}
+
+// response returns the Go type of the JSON body of the first success
+// response of an operation, declaring <Name>Response for an inline object,
+// and any when it has none
+func (t *schemaTypes) response(name string, op *openapi.Operation) (string, error) {
+	codes := make([]string, 0, len(op.Responses))
+	for _, r := range op.Responses {
+		if strings.HasPrefix(r.Name, "2") {
+			codes = append(codes, r.Name)
+		}
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		r, _ := op.Responses.Get(code)
+		r, err := t.doc.Response(r)
+		if err != nil {
+			return "", err
+		}
+		if r == nil {
+			continue
+		}
+		if schema := openapi.JSONSchema(r.Content); schema != nil {
+			return t.bodyType(schema, name+"Response", "response body of "+name)
+		}
+	}
+	return "any", nil
+}
+
+// bodyType returns the Go type of a body in the handlers package
+func (t *schemaTypes) bodyType(s *openapi.Schema, name, what string) (string, error) {
+	if t.isStruct(s) {
+		if err := t.declare(name, s, []string{name + " is the " + what}); err != nil {
+			return "", err
+		}
+		return "models." + name, nil
+	}
+	return t.goType(s, name, "models.")
+}
+
+// sentences returns the lines of a description written in a doc comment,
+// ending with a period so that gofmt does not turn a single line into a
+// heading
+func sentences(text string) []string {
+	text = strings.TrimSpace(text)
+	if !strings.ContainsAny(text[len(text)-1:], ".!?:") {
+		text += "."
+	}
+	return strings.Split(text, "\n")
+}
+
+// pointerLike reports whether the zero value of a Go type is nil, so that
+// it holds null without being made a pointer
+func pointerLike(typ string) bool {
+	return typ == "any" || strings.HasPrefix(typ, "*") || strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "map[")
+}
@@ -0,0 +1,53 @@
+package scaffold
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGenerateOpenAPIChanged checks that generating from a changed document
+// rewrites the generated models file without Force, keeps the declared
+// handlers, and refuses a models file written by hand
+func TestGenerateOpenAPIChanged(t *testing.T) {
+	p := Project{Dir: filepath.Join(t.TempDir(), "example"), Module: "example.com/example", Framework: "chi"}
+	writeRoutes(t, p)
+	spec := filepath.Join("testdata", "openapi.yaml")
+	if _, err := GenerateOpenAPI(p, OpenAPIOptions{Spec: spec}); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := filepath.Join(t.TempDir(), "openapi.yaml")
+	content = []byte(strings.Replace(string(content), "  /pets/{petId}:\n", "  /owners:\n    get:\n      operationId: listOwners\n      responses:\n        '200': {description: Owners}\n  /pets/{petId}:\n", 1))
+	content = append(content, "    Tag:\n      type: string\n"...)
+	if err := os.WriteFile(changed, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	generated, err := GenerateOpenAPI(p, OpenAPIOptions{Spec: changed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(generated.Files) != 1 || len(generated.Kept) != 5 || len(generated.Modified) != 2 {
+		t.Errorf("got %+v, want the new handler written, the others kept and the models and routes updated", generated)
+	}
+	models := filepath.Join(p.Dir, filepath.FromSlash(openAPIModels))
+	if after, _ := os.ReadFile(models); !strings.Contains(string(after), "type Tag string") {
+		t.Error("the models file was not rewritten")
+	}
+
+	if err := os.WriteFile(models, []byte("package models\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, force := range []bool{false, true} {
+		if _, err := GenerateOpenAPI(p, OpenAPIOptions{Spec: spec, Force: force}); !errors.Is(err, ErrExists) {
+			t.Errorf("force %v: got %v, want the hand-written models refused", force, err)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pol-cova/GoGinit/config"
+	"github.com/pol-cova/GoGinit/internal/db"
+	"github.com/pol-cova/GoGinit/runner"
+	"github.com/pol-cova/GoGinit/templates"
+)
+
+// Project is an existing Go module, usually one created by Generate, that
+// the generators add code to
+type Project struct {
+	Dir       string // Module root, the directory holding go.mod
+	Module    string // Module path
+	GoVersion string // go directive of go.mod
+	Framework string // Framework the project is built on
+	Database  string // Database set up in the project, such as config.DatabaseSQLite
+}
+
+// OpenProject opens the Go module containing dir. The database is SQLite
+// when the module imports its driver. The framework is the
+// registered framework whose packages the module imports, net/http based
+// "default" when it imports none. When several are imported, the framework
+// recorded in goginit.yaml decides; when it cannot, the Project is returned
+// with an empty Framework along with the error.
+func OpenProject(ctx context.Context, r runner.Runner, dir string) (Project, error) {
+	if r == nil {
+		r = &runner.Exec{}
+	}
+
+	root, err := findModuleRoot(dir)
+	if err != nil {
+		return Project{}, err
+	}
+	mod, err := config.ReadGoMod(ctx, r, root)
+	if err != nil {
+		return Project{}, err
+	}
+
+	p := Project{Dir: root, Module: mod.Module.Path, GoVersion: mod.Go}
+	imports, err := moduleImports(root)
+	if err != nil {
+		return Project{}, err
+	}
+	if requires(mod, db.SQLitePackage) && imports[db.SQLitePackage] {
+		p.Database = config.DatabaseSQLite
+	}
+	p.Framework, err = detectFramework(root, mod, imports)
+	return p, err
+}
+
+// templateData returns the project variables available to generator templates
+func (p Project) templateData() templates.Data {
+	data := templates.Data{
+		ProjectName: filepath.Base(p.Dir),
+		ModulePath:  p.Module,
+		Framework:   p.Framework,
+		Database:    p.Database,
+		GoVersion:   p.GoVersion,
+	}
+	if f, err := config.GetFrameworkConfig(p.Framework); err == nil {
+		data.FrameworkImport = f.ImportPath
+	}
+	return data
+}
+
+// findModuleRoot returns dir or its closest parent holding a go.mod
+func findModuleRoot(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for d := abs; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d, nil
+		}
+		if filepath.Dir(d) == d {
+			return "", fmt.Errorf("no go.mod found in %s or any parent directory", abs)
+		}
+	}
+}
+
+// moduleImports returns the import paths of every Go file of the module
+func moduleImports(root string) (map[string]bool, error) {
+	imports := map[string]bool{}
+	fset := token.NewFileSet()
+	err := walkModule(root, func(path string) error {
+		f, err := parser.ParseFile(fset, path, nil, parser.ImportsOnly)
+		if err != nil {
+			return err
+		}
+		for _, spec := range f.Imports {
+			if p, err := strconv.Unquote(spec.Path.Value); err == nil {
+				imports[p] = true
+			}
+		}
+		return nil
+	})
+	return imports, err
+}
+
+// walkModule calls fn for every Go file of the module in lexical order,
+// skipping nested modules and the directories ignored by the go command
+func walkModule(root string, fn func(path string) error) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path == root {
+				return nil
+			}
+			name := d.Name()
+			if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor" {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		return fn(path)
+	})
+}
+
+// detectFramework returns the registered framework required by the module
+// and imported by its code
+func detectFramework(root string, mod config.GoMod, imports map[string]bool) (string, error) {
+	var found []string
+	for _, f := range config.Frameworks() {
+		if f.ImportPath == "" || !requires(mod, f.ImportPath) {
+			continue
+		}
+		for p := range imports {
+			if p == f.ImportPath || strings.HasPrefix(p, f.ImportPath+"/") {
+				found = append(found, f.Name)
+				break
+			}
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return "default", nil
+	case 1:
+		return found[0], nil
+	}
+
+	spec, err := config.LoadSpec(filepath.Join(root, config.SpecFileName))
+	if err == nil {
+		for _, name := range found {
+			if name == spec.Framework {
+				return name, nil
+			}
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	sort.Strings(found)
+	return "", fmt.Errorf("the project imports several frameworks (%s) and %s names none of them", strings.Join(found, ", "), config.SpecFileName)
+}
+
+// requires reports whether the module requires the module providing the
+// package importPath
+func requires(mod config.GoMod, importPath string) bool {
+	for _, req := range mod.Require {
+		if importPath == req.Path || strings.HasPrefix(importPath, req.Path+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,200 @@
+package scaffold
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pol-cova/GoGinit/internal/naming"
+	"github.com/pol-cova/GoGinit/internal/routing"
+	"github.com/pol-cova/GoGinit/templates"
+)
+
+// resourceActions are the handler methods of a resource, with their method
+// and whether they act on a single item
+var resourceActions = []struct {
+	Handler string
+	Method  string
+	Item    bool
+}{
+	{"List", "GET", false},
+	{"Create", "POST", false},
+	{"Get", "GET", true},
+	{"Update", "PUT", true},
+	{"Delete", "DELETE", true},
+}
+
+// GenerateResource writes everything serving a model over HTTP: the model
+// and its table as GenerateModel does, a repository in pkg/db using the
+// InitDB connection, list, get, create, update and delete handlers in
+// internal/handlers with their tests, and registers the routes in
+// routes.Register. When the project has no such function, the routes to add
+// are returned instead. The project needs a database.
+func GenerateResource(p Project, opts ModelOptions) (Generated, error) {
+	if p.Database == "" {
+		return Generated{}, fmt.Errorf("resources are stored in the project database, set it up with goginit init --db")
+	}
+	data, style, err := resourceData(p, opts)
+	if err != nil {
+		return Generated{}, err
+	}
+
+	files := map[string]string{}
+	for _, variant := range [][2]string{
+		{"model", "models"},
+		{"model", p.Database},
+		{"resource", p.Database},
+		{"resource", "common"},
+		{"resource", p.Framework},
+	} {
+		rendered, err := renderGenerator(variant[0], variant[1], data)
+		if err != nil {
+			return Generated{}, err
+		}
+		for name, content := range rendered {
+			files[name] = content
+		}
+	}
+
+	// Helpers shared by every resource are written once
+	shared, err := renderGenerator("resource", p.Database+"-shared", data)
+	if err != nil {
+		return Generated{}, err
+	}
+	for name, content := range shared {
+		if _, err := os.Stat(filepath.Join(p.Dir, filepath.FromSlash(name))); os.IsNotExist(err) {
+			files[name] = content
+		}
+	}
+
+	if err := checkDeclarations(p, files); err != nil {
+		return Generated{}, err
+	}
+	written, err := writeGenerated(p, files, opts.Force)
+	if err != nil {
+		return Generated{}, err
+	}
+	generated := Generated{Files: written}
+
+	constructor := fmt.Sprintf("%s := handlers.New%sHandler(db.New%sRepository(db.Conn()))", data.Var, data.Name, data.Name)
+	imports := []string{p.Module + "/internal/handlers", p.Module + "/pkg/db"}
+	statements := func(style routing.Style) []string {
+		return append([]string{constructor}, resourceRoutes(style, data, data.Var)...)
+	}
+	registered, changed, err := registerRoutes(p, routesFile, style, imports, statements)
+	if err != nil {
+		return generated, err
+	}
+	if changed {
+		generated.Modified = append(generated.Modified, routesFile)
+	}
+	if !registered {
+		generated.Routes = statements(style)
+	}
+	return generated, nil
+}
+
+// resourceData validates the options and returns the template variables
+func resourceData(p Project, opts ModelOptions) (templates.ResourceData, routing.Style, error) {
+	var data templates.ResourceData
+	style, ok := routing.Lookup(p.Framework, p.GoVersion)
+	if !ok {
+		return data, style, fmt.Errorf("generators do not support the %s framework", p.Framework)
+	}
+
+	model, err := modelData(p, opts)
+	if err != nil {
+		return data, style, err
+	}
+	data.ModelData = model
+	data.Unexported = naming.Camel(opts.Name)
+	data.Var = data.Unexported + "Handler"
+
+	for _, f := range data.Fields {
+		switch {
+		case f.PrimaryKey:
+			data.Key = f
+			data.KeyUUID = f.Column == "id" && !f.AutoIncrement && opts.PrimaryKey == PrimaryKeyUUID
+		case f.Column == "created_at":
+			data.Timestamps = !opts.NoTimestamps
+		}
+		if !f.AutoIncrement {
+			data.Inserted = append(data.Inserted, f)
+		}
+		if !f.PrimaryKey && !(data.Timestamps && f.Column == "created_at") {
+			data.Updated = append(data.Updated, f)
+		}
+	}
+	data.KeyVar = naming.Camel(data.Key.Column)
+	if token.IsKeyword(data.KeyVar) {
+		data.KeyVar = "key"
+	}
+	switch data.Key.Type {
+	case "string":
+	case "int", "int32", "int64", "uint":
+		data.KeyIsInt = true
+	default:
+		return data, style, fmt.Errorf("primary key %s must be a string or an integer", data.Key.Column)
+	}
+
+	path, err := routing.ParsePath("/" + naming.Kebab(data.Table) + "/:" + data.Key.Column)
+	if err != nil {
+		return data, style, err
+	}
+	if err := style.Check(path); err != nil {
+		return data, style, err
+	}
+	data.Path = "/" + naming.Kebab(data.Table)
+	data.ItemPath = style.Path(path)
+	data.Sample = sampleJSON(data)
+	data.Routes = resourceRoutes(style, data, "h")
+	return data, style, nil
+}
+
+// resourceRoutes returns the statements registering the handler methods of
+// the resource on the router of style
+func resourceRoutes(style routing.Style, data templates.ResourceData, handler string) []string {
+	collection, _ := routing.ParsePath(data.Path)
+	item, _ := routing.ParsePath(data.Path + "/:" + data.Key.Column)
+
+	routes := make([]string, len(resourceActions))
+	for i, action := range resourceActions {
+		path := collection
+		if action.Item {
+			path = item
+		}
+		routes[i] = style.Statement(action.Method, path, handler+"."+action.Handler)
+	}
+	return routes
+}
+
+// sampleJSON returns a JSON object setting every field not set by the
+// repository, used as request body by the generated tests
+func sampleJSON(data templates.ResourceData) string {
+	var values []string
+	for _, f := range data.Inserted {
+		if data.KeyUUID && f.PrimaryKey || data.Timestamps && (f.Column == "created_at" || f.Column == "updated_at") {
+			continue
+		}
+
+		var value string
+		switch strings.TrimPrefix(f.Type, "*") {
+		case "string":
+			value = `"example"`
+		case "bool":
+			value = "true"
+		case "float32", "float64":
+			value = "1.5"
+		case "time.Time":
+			value = `"2024-01-02T15:04:05Z"`
+		case "[]byte":
+			value = `"ZXhhbXBsZQ=="`
+		default:
+			value = "1"
+		}
+		values = append(values, fmt.Sprintf("%q:%s", f.Column, value))
+	}
+	return "{" + strings.Join(values, ",") + "}"
+}
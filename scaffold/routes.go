@@ -0,0 +1,164 @@
+package scaffold
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pol-cova/GoGinit/internal/astedit"
+	"github.com/pol-cova/GoGinit/internal/routing"
+)
+
+// routesFile holds the Register function of the generated projects
+const routesFile = "internal/routes/routes.go"
+
+// RouteOptions describes a route to register
+type RouteOptions struct {
+	// Method is the HTTP method of the route, GET when empty
+	Method string
+
+	// Path is the route path. Path parameters are written :name or {name},
+	// whatever the framework.
+	Path string
+
+	// Handler is the expression of the handler, such as handlers.GetUser
+	Handler string
+
+	// Import is the import path of the package of Handler. It defaults to
+	// the internal/handlers package of the project for handlers.<Name>.
+	Import string
+
+	// File registers the routes, relative to the project directory:
+	// internal/routes/routes.go when empty
+	File string
+}
+
+// AddRoute registers a route in a file of the project, where its router is
+// set up: the Register function of internal/routes, or the function
+// creating the router, such as r := gin.Default(). The handler package is
+// imported when needed. Nothing changes when the route is already
+// registered.
+func AddRoute(p Project, opts RouteOptions) (Generated, error) {
+	style, ok := routing.Lookup(p.Framework, p.GoVersion)
+	if !ok {
+		return Generated{}, fmt.Errorf("generators do not support the %s framework", p.Framework)
+	}
+
+	method := strings.ToUpper(opts.Method)
+	if method == "" {
+		method = "GET"
+	}
+	if !validMethod(method) {
+		return Generated{}, fmt.Errorf("unsupported method %s, use one of %s", opts.Method, strings.Join(routing.Methods, ", "))
+	}
+	path, err := routing.ParsePath(opts.Path)
+	if err != nil {
+		return Generated{}, err
+	}
+	if err := style.Check(path); err != nil {
+		return Generated{}, err
+	}
+
+	expr, err := parser.ParseExpr(opts.Handler)
+	if err != nil {
+		return Generated{}, fmt.Errorf("invalid handler %q: %w", opts.Handler, err)
+	}
+	var imports []string
+	if opts.Import != "" {
+		imports = append(imports, opts.Import)
+	} else if sel, ok := expr.(*ast.SelectorExpr); ok {
+		if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == "handlers" {
+			imports = append(imports, p.Module+"/internal/handlers")
+		}
+	}
+
+	file := opts.File
+	if file == "" {
+		file = routesFile
+	}
+	if _, err := os.Stat(filepath.Join(p.Dir, filepath.FromSlash(file))); err != nil {
+		return Generated{}, err
+	}
+	statements := func(style routing.Style) []string {
+		return []string{style.Statement(method, path, opts.Handler)}
+	}
+	registered, changed, err := registerRoutes(p, file, style, imports, statements)
+	if err != nil {
+		return Generated{}, err
+	}
+	if !registered {
+		return Generated{}, fmt.Errorf("no router found in %s, routes are registered in a Register function or where the router is created", file)
+	}
+	var generated Generated
+	if changed {
+		generated.Modified = []string{filepath.ToSlash(file)}
+	}
+	return generated, nil
+}
+
+// registerRoutes adds statements where the router of a file of the project
+// is set up, see astedit.File.Router, with the imports they need. The
+// statements are built for the name of the router; those already present
+// are skipped. It reports whether the routes are registered, false when the
+// file does not exist or has no router, and whether the file changed.
+func registerRoutes(p Project, file string, style routing.Style, imports []string, statements func(routing.Style) []string) (registered, changed bool, err error) {
+	f, err := astedit.ParseFile(filepath.Join(p.Dir, filepath.FromSlash(file)))
+	if os.IsNotExist(err) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	router, ok := f.Router()
+	if !ok {
+		return false, false, nil
+	}
+	style.Router = router.Name
+
+	added, err := f.AddStatements(router, statements(style)...)
+	if err != nil || added == 0 {
+		return err == nil, false, err
+	}
+	for _, imp := range imports {
+		f.AddImport(imp)
+	}
+	if err := f.Write(); err != nil {
+		return false, false, fmt.Errorf("error adding routes to %s: %w", file, err)
+	}
+	return true, true, nil
+}
+
+// ListRoutes returns the routes registered by the non-test Go files of the
+// project, found by reading their syntax in the style of the project's
+// framework. The file of every route is slash-separated and relative to the
+// project directory.
+func ListRoutes(p Project) ([]routing.Route, error) {
+	style, ok := routing.Lookup(p.Framework, p.GoVersion)
+	if !ok {
+		return nil, fmt.Errorf("routes of the %s framework cannot be listed", p.Framework)
+	}
+
+	var routes []routing.Route
+	fset := token.NewFileSet()
+	err := walkModule(p.Dir, func(path string) error {
+		if strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		for _, route := range style.Scan(fset, f) {
+			if rel, err := filepath.Rel(p.Dir, route.File); err == nil {
+				route.File = filepath.ToSlash(rel)
+			}
+			routes = append(routes, route)
+		}
+		return nil
+	})
+	return routes, err
+}
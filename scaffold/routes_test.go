@@ -0,0 +1,291 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pol-cova/GoGinit/internal/routing"
+)
+
+// TestAddRoute registers routes in hand-written files and checks the edits
+// land where the router is set up, once
+func TestAddRoute(t *testing.T) {
+	tests := []struct {
+		name      string
+		framework string
+		src       string
+		want      string
+	}{
+		{
+			name:      "main",
+			framework: "gin",
+			src: `package main
+
+import "github.com/gin-gonic/gin"
+
+func main() {
+	router := gin.Default()
+	router.Use(gin.Recovery()) // keep first
+
+	// Start serving
+	router.Run(":8080")
+}
+`,
+			want: `package main
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"example.com/example/internal/handlers"
+)
+
+func main() {
+	router := gin.Default()
+	router.Use(gin.Recovery()) // keep first
+	router.GET("/users/:id", handlers.GetUser)
+
+	// Start serving
+	router.Run(":8080")
+}
+`,
+		},
+		{
+			name:      "default mux",
+			framework: "default",
+			src: `package main
+
+import (
+	"log"
+	"net/http"
+)
+
+func main() {
+	http.HandleFunc("/", home)
+	log.Fatal(http.ListenAndServe(":8080", nil))
+}
+`,
+			want: `package main
+
+import (
+	"log"
+	"net/http"
+
+	"example.com/example/internal/handlers"
+)
+
+func main() {
+	http.HandleFunc("/", home)
+	http.HandleFunc("GET /users/{id}", handlers.GetUser)
+	log.Fatal(http.ListenAndServe(":8080", nil))
+}
+`,
+		},
+		{
+			name:      "register",
+			framework: "chi",
+			src: `package routes
+
+import "github.com/go-chi/chi/v5"
+
+func Register(router chi.Router) {}
+`,
+			want: `package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+
+	"example.com/example/internal/handlers"
+)
+
+func Register(router chi.Router) {
+	router.Get("/users/{id}", handlers.GetUser)
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Project{Dir: t.TempDir(), Module: "example.com/example", Framework: tt.framework}
+			if err := os.WriteFile(filepath.Join(p.Dir, "main.go"), []byte(tt.src), 0644); err != nil {
+				t.Fatal(err)
+			}
+			opts := RouteOptions{Path: "/users/:id", Handler: "handlers.GetUser", File: "main.go"}
+
+			for i, modified := range []int{1, 0} {
+				generated, err := AddRoute(p, opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(generated.Modified) != modified {
+					t.Errorf("run %d modified %v, want %d files", i+1, generated.Modified, modified)
+				}
+			}
+			got, err := os.ReadFile(filepath.Join(p.Dir, "main.go"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestListRoutes checks the routes found in files using router groups and
+// the registration forms of several frameworks
+func TestListRoutes(t *testing.T) {
+	tests := []struct {
+		framework string
+		src       string
+		want      []routing.Route
+	}{
+		{
+			framework: "gin",
+			src: `package main
+
+import "github.com/gin-gonic/gin"
+
+func main() {
+	r := gin.Default()
+	api := r.Group("/api")
+	api.GET("/users/:id", auth, handlers.GetUser)
+	r.Any("/ping", func(c *gin.Context) {})
+}
+`,
+			want: []routing.Route{
+				{Method: "GET", Path: "/api/users/:id", Handler: "handlers.GetUser", File: "main.go", Line: 8},
+				{Method: "ANY", Path: "/ping", Handler: "(func(c *gin.Context) literal)", File: "main.go", Line: 9},
+			},
+		},
+		{
+			framework: "echo",
+			src: `package main
+
+import "github.com/labstack/echo/v4"
+
+func main() {
+	e := echo.New()
+	e.POST("/orders", h.Create, middleware.BodyLimit("1M"))
+}
+`,
+			want: []routing.Route{
+				{Method: "POST", Path: "/orders", Handler: "h.Create", File: "main.go", Line: 7},
+			},
+		},
+		{
+			framework: "chi",
+			src: `package main
+
+import "github.com/go-chi/chi/v5"
+
+func main() {
+	r := chi.NewRouter()
+	r.Route("/api", func(r chi.Router) {
+		r.Get("/users/{id}", handlers.GetUser)
+	})
+	r.Delete("/users/{id}", handlers.DeleteUser)
+}
+`,
+			want: []routing.Route{
+				{Method: "GET", Path: "/api/users/{id}", Handler: "handlers.GetUser", File: "main.go", Line: 8},
+				{Method: "DELETE", Path: "/users/{id}", Handler: "handlers.DeleteUser", File: "main.go", Line: 10},
+			},
+		},
+		{
+			// HTTP clients and caches have Get methods too
+			framework: "chi",
+			src: `package main
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func main() {
+	r := chi.NewRouter()
+	client := &http.Client{}
+	req, _ := http.NewRequest("GET", "/users", nil)
+	client.Do(req)
+	cache.Get("/users", fallback)
+	r.With(auth).Get("/users", handlers.Users)
+}
+`,
+			want: []routing.Route{
+				{Method: "GET", Path: "/users", Handler: "handlers.Users", File: "main.go", Line: 15},
+			},
+		},
+		{
+			framework: "fiber",
+			src: `package routes
+
+import "github.com/gofiber/fiber/v2"
+
+func Register(app *fiber.App, store Store) {
+	api := app.Group("/api")
+	api.Get("/orders", handlers.Orders)
+	store.Get("/orders", nil)
+	registerUsers(api)
+}
+
+func registerUsers(r fiber.Router) {
+	r.Post("/users", handlers.CreateUser)
+	cache.Get("/users", "all")
+}
+`,
+			want: []routing.Route{
+				{Method: "GET", Path: "/api/orders", Handler: "handlers.Orders", File: "main.go", Line: 7},
+				{Method: "POST", Path: "/users", Handler: "handlers.CreateUser", File: "main.go", Line: 13},
+			},
+		},
+		{
+			framework: "mux",
+			src: `package main
+
+func main() {
+	r := mux.NewRouter()
+	api := r.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/users", handlers.Users).Methods("GET", "POST")
+	r.Handle("/static/", files)
+}
+`,
+			want: []routing.Route{
+				{Method: "GET,POST", Path: "/api/users", Handler: "handlers.Users", File: "main.go", Line: 6},
+				{Method: "ANY", Path: "/static/", Handler: "files", File: "main.go", Line: 7},
+			},
+		},
+		{
+			framework: "default",
+			src: `package main
+
+func main() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", handlers.GetUser)
+	http.Get("https://example.com")
+}
+`,
+			want: []routing.Route{
+				{Method: "GET", Path: "/users/{id}", Handler: "handlers.GetUser", File: "main.go", Line: 5},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.framework, func(t *testing.T) {
+			p := Project{Dir: t.TempDir(), Module: "example.com/example", Framework: tt.framework}
+			if err := os.WriteFile(filepath.Join(p.Dir, "main.go"), []byte(tt.src), 0644); err != nil {
+				t.Fatal(err)
+			}
+			routes, err := ListRoutes(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(routes, tt.want) {
+				t.Errorf("got %+v, want %+v", routes, tt.want)
+			}
+		})
+	}
+}
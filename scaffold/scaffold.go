@@ -0,0 +1,498 @@
+// Package scaffold generates GoGinit projects. It is what the goginit init
+// command runs, usable from other Go programs:
+//
+//	result, err := scaffold.Generate(ctx, scaffold.Options{
+//		Spec: config.ProjectSpec{Name: "myservice", Framework: "gin"},
+//	})
+//
+// Generate never prints unless Options.Output is set and reports failures as
+// errors: *StepError for a failed generation step, *HookError for a failed
+// hook, *VerifyError when the new project does not build,
+// *config.MissingModulesError in offline mode, and ErrInterrupted when ctx is
+// cancelled before the project is written or *InterruptedError after.
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pol-cova/GoGinit/config"
+	"github.com/pol-cova/GoGinit/internal/db"
+	"github.com/pol-cova/GoGinit/internal/goversion"
+	"github.com/pol-cova/GoGinit/internal/openapi"
+	"github.com/pol-cova/GoGinit/runner"
+)
+
+// ErrInterrupted is returned when the context is cancelled during generation,
+// before the project is written
+var ErrInterrupted = errors.New("scaffolding interrupted, no project was created")
+
+// InterruptedError is returned when the context is cancelled after the
+// project was written, while the post hooks or the verification run. The
+// project is kept on disk.
+type InterruptedError struct {
+	Dir string // Project directory
+}
+
+func (e *InterruptedError) Error() string {
+	return "interrupted after the project was written to " + e.Dir
+}
+
+// Options configures a project generation
+type Options struct {
+	// Spec holds the scaffolding choices. Name and Framework are required.
+	Spec config.ProjectSpec
+
+	// SpecDir is the directory of the file Spec was read from. Relative
+	// script paths of the spec hooks are resolved against it when the hooks
+	// run, and recorded as written. They are relative to the current
+	// directory when empty.
+	SpecDir string
+
+	// Dir is the directory the project directory is created in, the current
+	// directory when empty
+	Dir string
+
+	// Runner runs the go commands, a runner.Exec when nil
+	Runner runner.Runner
+
+	// Offline resolves the dependencies from the local module cache, or from
+	// the file-based GOPROXY directory ProxyDir when set, without network
+	Offline  bool
+	ProxyDir string
+
+	// SkipVerify skips running go build and go vet in the new project
+	SkipVerify bool
+
+	// OpenAPI is the path of an OpenAPI 3 document whose models, handler
+	// stubs and routes are generated in the new project, see
+	// GenerateOpenAPI
+	OpenAPI string
+
+	// Hooks run before the hooks of Spec, for instance hooks from the user
+	// config. Unlike the spec hooks, they are not recorded in the project.
+	Hooks config.Hooks
+
+	// Output receives progress messages, nothing is printed when nil
+	Output io.Writer
+}
+
+// Result describes a generated project
+type Result struct {
+	Dir      string             // Project directory
+	Spec     config.ProjectSpec // Spec recorded in the project's goginit.yaml
+	Files    []string           // Created files, slash-separated and relative to Dir
+	Commands []runner.Command   // Commands run, in order
+}
+
+// Step names a stage of the generation
+type Step string
+
+// Generation stages reported by StepError
+const (
+	StepPrepare      Step = "prepare"
+	StepWrite        Step = "write files"
+	StepModule       Step = "initialize module"
+	StepDependencies Step = "fetch dependencies"
+	StepDatabase     Step = "set up database"
+	StepOpenAPI      Step = "generate from OpenAPI"
+	StepCommit       Step = "move project into place"
+)
+
+// StepError reports the generation step that failed. No project directory
+// is left behind.
+type StepError struct {
+	Step Step
+	Err  error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Step, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// Generate creates the project described by opts. The project is generated
+// in a staging directory and only moved into place once every step
+// succeeded. Pre hooks run before and post hooks right after that move.
+// When a post hook or verification fails, the project is kept and both the
+// Result and the error are returned.
+func Generate(ctx context.Context, opts Options) (result Result, err error) {
+	spec, err := resolveSpec(opts.Spec)
+	if err != nil {
+		return Result{}, err
+	}
+	result = Result{Dir: filepath.Join(opts.Dir, spec.Name), Spec: spec}
+	out := opts.Output
+	if out == nil {
+		out = io.Discard
+	}
+
+	// Record the commands as run, with the offline environment
+	recorder := &runner.Recorder{Runner: opts.Runner}
+	if recorder.Runner == nil {
+		recorder.Runner = &runner.Exec{}
+	}
+	defer func() {
+		result.Commands = recorder.Commands
+	}()
+	var r runner.Runner = recorder
+	if opts.offline() {
+		env, err := config.OfflineEnv(ctx, r, opts.ProxyDir)
+		if err != nil {
+			return result, err
+		}
+		r = runner.WithEnv(r, env...)
+		if err := checkOffline(ctx, r, spec, opts.ProxyDir); err != nil {
+			return result, err
+		}
+	}
+
+	files, err := projectFiles(templateSpec(ctx, r, spec))
+	if err != nil {
+		return result, &StepError{StepPrepare, err}
+	}
+	// Refuse to overwrite an existing project before doing any work
+	if err := config.CheckProjectDir(result.Dir); err != nil {
+		return result, &StepError{StepPrepare, err}
+	}
+	if opts.OpenAPI != "" {
+		if _, err := openapi.Load(opts.OpenAPI); err != nil {
+			return result, &StepError{StepPrepare, err}
+		}
+	}
+
+	hooks := opts.Hooks.Append(spec.Hooks)
+	if err := runHooks(ctx, r, out, PhasePre, hooks.Pre, opts.SpecDir, opts.Dir, result.Dir, spec); err != nil {
+		return result, err
+	}
+
+	// A failure or cancellation leaves no partial project behind
+	staging, err := newStagingDir(result.Dir)
+	if err != nil {
+		return result, &StepError{StepPrepare, err}
+	}
+	defer os.RemoveAll(staging)
+
+	if err := generateProject(ctx, r, out, staging, spec, files); err != nil {
+		if ctx.Err() != nil {
+			// The go command failed because it was cancelled
+			return result, ErrInterrupted
+		}
+		var stepErr *StepError
+		if opts.offline() && errors.As(err, &stepErr) {
+			if missing := config.ParseMissingModules(stepErr.Err.Error()); len(missing) > 0 {
+				return result, &config.MissingModulesError{Modules: missing}
+			}
+		}
+		return result, err
+	}
+	if opts.OpenAPI != "" {
+		if err := generateFromOpenAPI(ctx, r, out, staging, spec, opts.OpenAPI); err != nil {
+			return result, &StepError{StepOpenAPI, err}
+		}
+	}
+	if ctx.Err() != nil {
+		return result, ErrInterrupted
+	}
+	if result.Files, err = listFiles(staging); err != nil {
+		return result, &StepError{StepCommit, err}
+	}
+	if err := commitStagingDir(staging, result.Dir); err != nil {
+		return result, &StepError{StepCommit, err}
+	}
+	fmt.Fprintln(out, "Project created successfully, Happy Coding! 🎉")
+
+	if err := runHooks(ctx, r, out, PhasePost, hooks.Post, opts.SpecDir, result.Dir, result.Dir, spec); err != nil {
+		return result, err
+	}
+	if opts.SkipVerify {
+		return result, nil
+	}
+	// Make template regressions surface now rather than on the first run
+	return result, verifyProject(ctx, r, out, result.Dir)
+}
+
+// Plan describes what Generate would create and run, without touching the disk
+type Plan struct {
+	Dir      string             // Project directory
+	Spec     config.ProjectSpec // Spec recorded in the project's goginit.yaml
+	Dirs     []string           // Skeleton directories, relative to Dir
+	Files    map[string]string  // File contents by path relative to Dir, except go.mod and go.sum
+	Commands []runner.Command   // Commands Generate runs, in order
+}
+
+// PlanProject returns the plan of Generate for opts, after the same checks.
+// Like Generate, it runs go version through opts.Runner to render the
+// templates for the installed toolchain when the spec targets no version.
+// The files generated from opts.OpenAPI are planned in a temporary copy of
+// the project; nothing is written to opts.Dir.
+func PlanProject(ctx context.Context, opts Options) (Plan, error) {
+	spec, err := resolveSpec(opts.Spec)
+	if err != nil {
+		return Plan{}, err
+	}
+	plan := Plan{Dir: filepath.Join(opts.Dir, spec.Name), Spec: spec, Dirs: projectDirs(spec)}
+
+	if err := config.CheckProjectDir(plan.Dir); err != nil {
+		return plan, err
+	}
+	if opts.OpenAPI != "" {
+		if _, err := openapi.Load(opts.OpenAPI); err != nil {
+			return plan, err
+		}
+	}
+	r := opts.Runner
+	if r == nil {
+		r = &runner.Exec{}
+	}
+	rendered := templateSpec(ctx, r, spec)
+	if plan.Files, err = finalFiles(spec, rendered); err != nil {
+		return plan, err
+	}
+	if opts.OpenAPI != "" {
+		if err := planOpenAPI(plan.Files, rendered, opts.OpenAPI); err != nil {
+			return plan, &StepError{StepOpenAPI, err}
+		}
+	}
+
+	frameworkConfig, err := spec.FrameworkConfig()
+	if err != nil {
+		return plan, err
+	}
+	hooks := opts.Hooks.Append(spec.Hooks)
+	if plan.Commands, err = hookCommands(PhasePre, hooks.Pre, opts.SpecDir, opts.Dir, plan.Dir, spec); err != nil {
+		return plan, err
+	}
+	plan.Commands = append(plan.Commands, runner.Go(plan.Dir, "mod", "init", spec.ModulePath()))
+	if spec.GoVersion != "" {
+		plan.Commands = append(plan.Commands, config.GoDirectivesCommand(plan.Dir, spec.GoVersion, spec.Toolchain))
+	}
+	if frameworkConfig.ImportPath != "" {
+		plan.Commands = append(plan.Commands, runner.Go(plan.Dir, "get", frameworkConfig.Dependency()))
+	}
+	if spec.SetupDB() {
+		plan.Commands = append(plan.Commands, runner.Go(plan.Dir, "get", config.SQLiteDependency()))
+	}
+	postHooks, err := hookCommands(PhasePost, hooks.Post, opts.SpecDir, plan.Dir, plan.Dir, spec)
+	if err != nil {
+		return plan, err
+	}
+	plan.Commands = append(plan.Commands, postHooks...)
+	if !opts.SkipVerify {
+		for _, args := range verifyCommands {
+			plan.Commands = append(plan.Commands, runner.Command{Dir: plan.Dir, Name: args[0], Args: args[1:]})
+		}
+	}
+	return plan, nil
+}
+
+// templateSpec returns the spec the templates are rendered for. Without a
+// target, they follow the installed toolchain, which go mod init writes as
+// the go directive.
+func templateSpec(ctx context.Context, r runner.Runner, spec config.ProjectSpec) config.ProjectSpec {
+	if spec.GoVersion == "" {
+		if local, err := config.GetGoVersion(ctx, r); err == nil && goversion.IsValid(local) {
+			spec.GoVersion = local
+		}
+	}
+	return spec
+}
+
+// resolveSpec checks the spec and records the framework version actually
+// fetched, so the template code and the dependency version are known to match
+func resolveSpec(spec config.ProjectSpec) (config.ProjectSpec, error) {
+	if spec.Name == "" || spec.Framework == "" {
+		return spec, errors.New("project name and framework are required")
+	}
+	if err := spec.Validate(); err != nil {
+		return spec, err
+	}
+	frameworkConfig, err := spec.FrameworkConfig()
+	if err != nil {
+		return spec, err
+	}
+	spec.FrameworkVersion = frameworkConfig.Version
+	return spec, nil
+}
+
+// offline reports whether the options ask for offline resolution
+func (o Options) offline() bool {
+	return o.Offline || o.ProxyDir != ""
+}
+
+// checkOffline fails early, listing every dependency of the spec that is not
+// available offline
+func checkOffline(ctx context.Context, r runner.Runner, spec config.ProjectSpec, proxyDir string) error {
+	frameworkConfig, err := spec.FrameworkConfig()
+	if err != nil {
+		return err
+	}
+	var deps []string
+	if frameworkConfig.ImportPath != "" {
+		deps = append(deps, frameworkConfig.Dependency())
+	}
+	if spec.SetupDB() {
+		deps = append(deps, config.SQLiteDependency())
+	}
+
+	missing, err := config.MissingModules(ctx, r, deps, proxyDir)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return &config.MissingModulesError{Modules: missing}
+	}
+	return nil
+}
+
+// generateProject writes the whole project into root, checking for
+// interruption between steps
+func generateProject(ctx context.Context, r runner.Runner, out io.Writer, root string, spec config.ProjectSpec, files map[string]string) error {
+	projectName, setupDB := spec.Name, spec.SetupDB()
+
+	if err := writeProjectFiles(root, spec, files); err != nil {
+		return &StepError{StepWrite, err}
+	}
+
+	// Initialize Go module
+	if err := ctx.Err(); err != nil {
+		return ErrInterrupted
+	}
+	if err := config.GenerateGoMod(ctx, r, out, root, spec.ModulePath()); err != nil {
+		return &StepError{StepModule, err}
+	}
+	if spec.GoVersion != "" {
+		if err := config.SetGoDirectives(ctx, r, root, spec.GoVersion, spec.Toolchain); err != nil {
+			return &StepError{StepModule, err}
+		}
+		fmt.Fprintln(out, "Targeting Go", spec.GoVersion)
+	}
+
+	frameworkConfig, err := spec.FrameworkConfig()
+	if err != nil {
+		return &StepError{StepPrepare, err}
+	}
+	fmt.Fprintln(out, "Successfully retrieved framework configuration:", frameworkConfig.Name)
+
+	// The native net/http template has no dependencies to fetch
+	if frameworkConfig.ImportPath != "" {
+		if err := ctx.Err(); err != nil {
+			return ErrInterrupted
+		}
+		if err := config.FetchFrameworkDependencies(ctx, r, root, frameworkConfig.Dependency()); err != nil {
+			return &StepError{StepDependencies, err}
+		}
+		fmt.Fprintln(out, "Successfully fetched framework dependencies for:", frameworkConfig.Dependency())
+	}
+
+	// Setup the database if required
+	if setupDB {
+		if err := ctx.Err(); err != nil {
+			return ErrInterrupted
+		}
+		if err := db.SetupDatabase(ctx, r, out, root, projectName, setupDB); err != nil {
+			return &StepError{StepDatabase, err}
+		}
+	}
+
+	// go get raises the go directive when a dependency needs a newer Go
+	if spec.GoVersion != "" {
+		goVersion, err := config.GoDirective(ctx, r, root)
+		if err != nil {
+			return &StepError{StepDependencies, err}
+		}
+		if goversion.IsValid(goVersion) && goversion.Compare(goVersion, spec.GoVersion) > 0 {
+			return &StepError{StepDependencies, fmt.Errorf("the dependencies require Go %s, the project targets Go %s", goVersion, spec.GoVersion)}
+		}
+	}
+
+	// Record the scaffolding choices in the project
+	if err := config.WriteSpec(root, spec); err != nil {
+		return &StepError{StepWrite, err}
+	}
+	return nil
+}
+
+// generateFromOpenAPI runs GenerateOpenAPI in the project written into root
+func generateFromOpenAPI(ctx context.Context, r runner.Runner, out io.Writer, root string, spec config.ProjectSpec, document string) error {
+	goVersion, err := config.GoDirective(ctx, r, root)
+	if err != nil {
+		return err
+	}
+	p := Project{Dir: root, Module: spec.ModulePath(), GoVersion: goVersion, Framework: spec.Framework}
+	if spec.SetupDB() {
+		p.Database = config.DatabaseSQLite
+	}
+	generated, err := GenerateOpenAPI(p, OpenAPIOptions{Spec: document})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Generated %d files from %s\n", len(generated.Files), filepath.Base(document))
+	if len(generated.Routes) > 0 {
+		fmt.Fprintln(out, "Register the routes of the OpenAPI operations:")
+		for _, route := range generated.Routes {
+			fmt.Fprintf(out, "\t%s\n", route)
+		}
+	}
+	return nil
+}
+
+// planOpenAPI adds the files GenerateOpenAPI writes or changes in a project
+// holding files to files. The generation runs on a copy of the project in a
+// temporary directory, removed afterwards.
+func planOpenAPI(files map[string]string, spec config.ProjectSpec, document string) error {
+	tmp, err := os.MkdirTemp("", "goginit-plan-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	// The project directory is named after the project, as the templates expect
+	root := filepath.Join(tmp, spec.Name)
+	if err := writeProjectFiles(root, spec, files); err != nil {
+		return err
+	}
+	p := Project{Dir: root, Module: spec.ModulePath(), GoVersion: spec.GoVersion, Framework: spec.Framework}
+	if spec.SetupDB() {
+		p.Database = config.DatabaseSQLite
+	}
+	generated, err := GenerateOpenAPI(p, OpenAPIOptions{Spec: document})
+	if err != nil {
+		return err
+	}
+	for _, file := range append(generated.Files, generated.Modified...) {
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+		if err != nil {
+			return err
+		}
+		files[file] = string(content)
+	}
+	return nil
+}
+
+// listFiles returns the files under root, slash-separated and sorted
+func listFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
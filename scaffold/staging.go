@@ -0,0 +1,39 @@
+package scaffold
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// newStagingDir creates a hidden staging directory next to the target, so the
+// final move stays on the same filesystem and is atomic
+func newStagingDir(target string) (string, error) {
+	parent := filepath.Dir(target)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return "", fmt.Errorf("error creating directory: %w", err)
+	}
+
+	staging, err := os.MkdirTemp(parent, "."+filepath.Base(target)+".goginit-*")
+	if err != nil {
+		return "", fmt.Errorf("error creating staging directory: %w", err)
+	}
+	return staging, nil
+}
+
+// commitStagingDir moves the finished staging directory to the target
+func commitStagingDir(staging, target string) error {
+	// MkdirTemp creates the directory as 0700
+	if err := os.Chmod(staging, 0755); err != nil {
+		return fmt.Errorf("error moving project into place: %w", err)
+	}
+
+	// An empty target directory passed config.CheckProjectDir and can be replaced
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error moving project into place: %w", err)
+	}
+	if err := os.Rename(staging, target); err != nil {
+		return fmt.Errorf("error moving project into place: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package scaffold
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pol-cova/GoGinit/runner"
+)
+
+// verifyCommands are run in a new project to check that it compiles with the
+// fetched dependencies
+var verifyCommands = [][]string{
+	{"go", "build", "./..."},
+	{"go", "vet", "./..."},
+}
+
+// diagnosticLine matches a compiler or vet diagnostic such as
+// internal/routes/routes.go:12:3: undefined: handlers.Home
+var diagnosticLine = regexp.MustCompile(`^(?:vet: )?(\.?[^\s:]+\.go):(\d+)(?::(\d+))?: (.*)$`)
+
+// Diagnostic is a compiler or vet message about a generated file
+type Diagnostic struct {
+	File    string // Path of the file, including the project directory
+	Line    int
+	Column  int // 0 when not reported
+	Message string
+	Source  string // The offending source line, empty when unavailable
+}
+
+func (d Diagnostic) String() string {
+	location := d.File + ":" + strconv.Itoa(d.Line)
+	if d.Column > 0 {
+		location += ":" + strconv.Itoa(d.Column)
+	}
+	return location + ": " + d.Message
+}
+
+// VerifyError reports that the generated project failed go build or go vet.
+// The project is kept on disk.
+type VerifyError struct {
+	Dir         string       // Project directory
+	Command     string       // Failing command, such as "go vet ./..."
+	Diagnostics []Diagnostic // Parsed file:line diagnostics
+	Output      string       // Full command output
+}
+
+func (e *VerifyError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "verification of the generated project failed, %s reported:\n", e.Command)
+	if len(e.Diagnostics) == 0 {
+		for _, line := range strings.Split(strings.TrimRight(e.Output, "\n"), "\n") {
+			fmt.Fprintf(&sb, "  %s\n", line)
+		}
+	}
+	for _, d := range e.Diagnostics {
+		fmt.Fprintf(&sb, "  %s\n", d)
+		if d.Source != "" {
+			fmt.Fprintf(&sb, "  %6d | %s\n", d.Line, d.Source)
+		}
+	}
+	fmt.Fprintf(&sb, "the project was kept in %s, this is likely a template bug", e.Dir)
+	return sb.String()
+}
+
+// verifyProject runs the verification commands in projectDir and returns a
+// *VerifyError describing the first failure
+func verifyProject(ctx context.Context, r runner.Runner, out io.Writer, projectDir string) error {
+	for _, args := range verifyCommands {
+		command := strings.Join(args, " ")
+		fmt.Fprintf(out, "Verifying project: %s", command)
+
+		result, err := r.Run(ctx, runner.Command{Dir: projectDir, Name: args[0], Args: args[1:]})
+		if err == nil {
+			fmt.Fprintln(out, " [OK]")
+			continue
+		}
+		fmt.Fprintln(out, " [FAILED]")
+		if ctx.Err() != nil {
+			return &InterruptedError{Dir: projectDir}
+		}
+
+		return &VerifyError{
+			Dir:         projectDir,
+			Command:     command,
+			Diagnostics: parseDiagnostics(projectDir, string(result.Output)),
+			Output:      string(result.Output),
+		}
+	}
+	return nil
+}
+
+// parseDiagnostics extracts the file:line diagnostics from go command output,
+// with paths made relative to the working directory
+func parseDiagnostics(projectDir, output string) []Diagnostic {
+	var diagnostics []Diagnostic
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		match := diagnosticLine.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+
+		d := Diagnostic{File: match[1], Message: match[4]}
+		if !filepath.IsAbs(d.File) {
+			d.File = filepath.Join(projectDir, d.File)
+		}
+		d.Line, _ = strconv.Atoi(match[2])
+		d.Column, _ = strconv.Atoi(match[3])
+		d.Source, _ = sourceLine(d.File, d.Line)
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics
+}
+
+// sourceLine returns line n, counted from 1, of the file at path
+func sourceLine(path string, n int) (string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	lines := strings.Split(string(data), "\n")
+	if n < 1 || n > len(lines) {
+		return "", false
+	}
+	return strings.TrimRight(lines[n-1], "\r"), true
+}
@@ -0,0 +1,108 @@
+package templates
+
+// HandlerData holds the variables available to the handler generator
+// templates, in addition to those of the project
+type HandlerData struct {
+	Data
+	Name   string  // Exported name of the handler function, such as GetUser
+	File   string  // File name in internal/handlers, without the .go suffix
+	Method string  // HTTP method, in upper case
+	Path   string  // Route path, in the syntax of the framework
+	Sample string  // Path requested by the test, with sample parameter values
+	Params []Param // Path parameters, in order
+}
+
+// Param is a path parameter of a route
+type Param struct {
+	Name   string // Name in the route path
+	Sample string // Value used by the generated tests
+}
+
+// ModelData holds the variables available to the model generator templates,
+// in addition to those of the project
+type ModelData struct {
+	Data
+	Name    string   // Exported name of the model struct, such as User
+	File    string   // File name in pkg/models, without the .go suffix
+	Table   string   // SQL table name, such as users
+	Fields  []Field  // Struct fields and table columns, in order
+	Imports []string // Packages imported by the model file
+}
+
+// Field is a field of a model and the matching table column
+type Field struct {
+	Name          string // Exported Go field name
+	Column        string // Column name, also used as JSON name
+	Type          string // Go type, a pointer for nullable fields
+	SQLType       string // Column type in the project database
+	Null          bool   // The column accepts NULL
+	Unique        bool   // The column has a UNIQUE constraint
+	PrimaryKey    bool   // The column is the primary key
+	AutoIncrement bool   // The primary key is assigned by the database
+	Default       string // SQL default value expression, empty for none
+}
+
+// ResourceData holds the variables available to the resource generator
+// templates, in addition to those of the model
+type ResourceData struct {
+	ModelData
+	Unexported string   // Unexported form of Name, such as userAccount
+	Var        string   // Name of the handler variable in routes.Register, such as userHandler
+	Key        Field    // Primary key field
+	KeyVar     string   // Name of the primary key parameters, such as id
+	KeyIsInt   bool     // The primary key is an integer, parsed from the path
+	KeyUUID    bool     // The primary key is a UUID set by Create
+	Timestamps bool     // The model has created_at and updated_at fields
+	Path       string   // Collection path, such as /users
+	ItemPath   string   // Item path in the syntax of the framework, such as /users/:id
+	Inserted   []Field  // Fields written by INSERT
+	Updated    []Field  // Fields written by UPDATE
+	Sample     string   // JSON body sent by the tests
+	Routes     []string // Routes of the router built by the tests, served by h
+}
+
+// OperationData holds the variables available to the handler stub templates
+// of the OpenAPI generator, in addition to those of a handler
+type OperationData struct {
+	HandlerData
+	Doc      []string // Summary and description of the operation, by line
+	Query    []string // Names of the query parameters
+	Body     string   // Go type of the JSON request body, empty for none
+	Response string   // Go type of the JSON success response, any for none
+	// BodyModels and ResponseModels report whether Body and Response use
+	// the models package
+	BodyModels, ResponseModels bool
+}
+
+// SchemaData holds the variables available to the models template of the
+// OpenAPI generator, in addition to those of the project
+type SchemaData struct {
+	Data
+	Source  string     // Base name of the OpenAPI document
+	Imports []string   // Packages imported by the models file
+	Types   []TypeDecl // Declared types, in document order
+}
+
+// TypeDecl is a type declared for a schema
+type TypeDecl struct {
+	Name   string        // Exported type name
+	Doc    []string      // Doc comment, by line
+	Type   string        // Underlying type, empty for a struct
+	Fields []SchemaField // Struct fields, in document order
+}
+
+// SchemaField is a field of a struct declared for an object schema
+type SchemaField struct {
+	Name string   // Exported Go field name
+	Type string   // Go type, a pointer for nullable fields
+	JSON string   // Value of the json tag, such as name,omitempty
+	Doc  []string // Description of the property, by line
+}
+
+// MiddlewareData holds the variables available to the middleware generator
+// templates, in addition to those of the project
+type MiddlewareData struct {
+	Data
+	Name string // Exported name of the middleware, such as RequestID
+	File string // File name in internal/middleware, without the .go suffix
+}
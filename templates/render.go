@@ -0,0 +1,138 @@
+package templates
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/pol-cova/GoGinit/internal/goversion"
+)
+
+// TemplateSuffix is stripped from rendered file names, so template files such
+// as main.go.tmpl are not picked up by Go tooling in the template directory
+const TemplateSuffix = ".tmpl"
+
+// Data holds the variables available to template files and file names
+type Data struct {
+	ProjectName      string // Project directory name
+	ModulePath       string // Go module path
+	Binary           string // Name of the cmd/<binary> main package
+	Framework        string // Framework name, such as "gin"
+	FrameworkImport  string // Import path of the framework, empty for net/http
+	FrameworkVersion string // Version of the framework fetched with go get
+	Database         string // Database name, empty when none is set up
+	GoVersion        string // Targeted Go version, empty for the latest
+}
+
+// goVersion is promoted to the data of the generators, which embed Data
+func (d Data) goVersion() string {
+	return d.GoVersion
+}
+
+// versioned is implemented by Data and by every type embedding it
+type versioned interface {
+	goVersion() string
+}
+
+// funcs are the helper functions available to templates. goAtLeast reports
+// whether the targeted Go version is at least the given one, so templates
+// can use newer features such as Go 1.22 ServeMux patterns.
+func funcs(data any) template.FuncMap {
+	var goVersion string
+	if v, ok := data.(versioned); ok {
+		goVersion = v.goVersion()
+	}
+
+	return template.FuncMap{
+		"lower":   strings.ToLower,
+		"upper":   strings.ToUpper,
+		"title":   title,
+		"replace": strings.ReplaceAll,
+		"goAtLeast": func(min string) (bool, error) {
+			if !goversion.IsValid(min) {
+				return false, fmt.Errorf("goAtLeast: invalid Go version %q", min)
+			}
+			return goversion.AtLeast(goVersion, min), nil
+		},
+	}
+}
+
+// title upper-cases the first letter of s and lower-cases the rest, turning
+// an HTTP method such as GET into Get
+func title(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + strings.ToLower(s[1:])
+}
+
+// Render executes every file of the template tree in fsys, rendering the
+// file names with text/template. The contents of the files named with
+// TemplateSuffix are rendered too, and the suffix is stripped; other files
+// are copied as is, so they may hold {{ or binary data. data is a Data, or
+// the data of a generator such as HandlerData. It returns the files keyed by
+// their slash-separated path relative to the tree root.
+func Render(fsys fs.FS, data any) (map[string]string, error) {
+	files := map[string]string{}
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		target, err := execute("path "+name, name, data)
+		if err != nil {
+			return err
+		}
+		target = path.Clean(strings.TrimSuffix(target, TemplateSuffix))
+		if target == "." || strings.HasPrefix(target, "../") || path.IsAbs(target) {
+			return fmt.Errorf("template file %s renders to invalid path %q", name, target)
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("error reading template file %s: %w", name, err)
+		}
+		rendered := string(content)
+		if strings.HasSuffix(name, TemplateSuffix) {
+			if rendered, err = execute(name, rendered, data); err != nil {
+				return err
+			}
+		}
+
+		if _, ok := files[target]; ok {
+			return fmt.Errorf("template file %s renders to duplicate path %s", name, target)
+		}
+		files[target] = rendered
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// RenderText renders a single template text, such as a hook command, with
+// the same variables and functions as template files
+func RenderText(name, text string, data any) (string, error) {
+	return execute(name, text, data)
+}
+
+// execute renders a single template text
+func execute(name, text string, data any) (string, error) {
+	t, err := template.New(name).Funcs(funcs(data)).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("error parsing template %s: %w", name, err)
+	}
+
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("error rendering template %s: %w", name, err)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,79 @@
+package templates
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRender(t *testing.T) {
+	fsys := fstest.MapFS{
+		"cmd/{{.Binary}}/main.go.tmpl":   {Data: []byte("package main // {{.ModulePath}}\n")},
+		".github/workflows/ci.yml":       {Data: []byte("key: ${{ secrets.KEY }}\n")},
+		"static/{{.ProjectName}}.png":    {Data: []byte{0x89, 'P', 'N', 'G', 0x00, 0xff}},
+		"docs/{{upper .ProjectName}}.md": {Data: []byte("{{ not rendered }}\n")},
+	}
+	data := Data{ProjectName: "example", ModulePath: "github.com/ourorg/example", Binary: "server"}
+
+	got, err := Render(fsys, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only .tmpl contents are rendered, other files are copied byte for byte
+	want := map[string]string{
+		"cmd/server/main.go":       "package main // github.com/ourorg/example\n",
+		".github/workflows/ci.yml": "key: ${{ secrets.KEY }}\n",
+		"static/example.png":       "\x89PNG\x00\xff",
+		"docs/EXAMPLE.md":          "{{ not rendered }}\n",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Render = %q, want %q", got, want)
+	}
+}
+
+func TestRenderInvalidPath(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		data Data
+		want string
+	}{
+		{
+			name: "parent",
+			fsys: fstest.MapFS{"{{.ProjectName}}.tmpl": {}},
+			data: Data{ProjectName: "../x"},
+			want: `renders to invalid path "../x"`,
+		},
+		{
+			name: "nested parent",
+			fsys: fstest.MapFS{"a/{{.ProjectName}}/b": {}},
+			data: Data{ProjectName: "../.."},
+			want: `renders to invalid path "../b"`,
+		},
+		{
+			name: "absolute",
+			fsys: fstest.MapFS{"{{.Database}}x": {}},
+			data: Data{Database: "/"},
+			want: `renders to invalid path "/x"`,
+		},
+		{
+			name: "root",
+			fsys: fstest.MapFS{"{{.Binary}}.tmpl": {}},
+			want: `renders to invalid path "."`,
+		},
+		{
+			name: "duplicate",
+			fsys: fstest.MapFS{"main.go": {}, "main.go.tmpl": {}},
+			want: "renders to duplicate path main.go",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Render(tt.fsys, tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want an error containing %s", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package templates
+
+import (
+	"embed"
+	"io/fs"
+	"path"
+)
+
+// builtin holds the built-in template trees. Template files carry the .tmpl
+// suffix so they are not compiled as part of this module.
+//
+//go:embed all:common all:frameworks all:generators
+var builtin embed.FS
+
+// Common is the template tree shared by every built-in framework
+var Common = mustSub("common")
+
+// Framework returns the built-in template tree of a framework, laid out as
+// the generated project: cmd/{{.Binary}}/main.go wiring the routes in
+// internal/routes, the handlers in internal/handlers and the middleware in
+// internal/middleware
+func Framework(name string) fs.FS {
+	return mustSub(path.Join("frameworks", name))
+}
+
+// Generator returns the template tree of a code generator, such as
+// "handler", for a variant: the framework of the project, the database for
+// schema files, or the signature family and kind of a middleware, such as
+// gin/cors. Its files are laid out relative to the project root. It reports
+// false when the generator has no such variant.
+func Generator(kind, variant string) (fs.FS, bool) {
+	dir := path.Join("generators", kind, variant)
+	if info, err := fs.Stat(builtin, dir); err != nil || !info.IsDir() {
+		return nil, false
+	}
+	return mustSub(dir), true
+}
+
+// mustSub returns the subtree of builtin rooted at dir
+func mustSub(dir string) fs.FS {
+	sub, err := fs.Sub(builtin, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}